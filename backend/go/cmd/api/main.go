@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"expvar"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
@@ -25,10 +30,15 @@ import (
 	"github.com/gino/cars-crud/internal/handler"
 	"github.com/gino/cars-crud/internal/middleware"
 	"github.com/gino/cars-crud/internal/queue"
+	memoryRepo "github.com/gino/cars-crud/internal/repository/memory"
 	mongoRepo "github.com/gino/cars-crud/internal/repository/mongo"
 	pgRepo "github.com/gino/cars-crud/internal/repository/postgres"
+	redisRepo "github.com/gino/cars-crud/internal/repository/redis"
 	"github.com/gino/cars-crud/internal/usecase"
+	"github.com/gino/cars-crud/pkg/certs"
 	"github.com/gino/cars-crud/pkg/config"
+	"github.com/gino/cars-crud/pkg/jwtkeys"
+	"github.com/gino/cars-crud/pkg/logger"
 )
 
 // @title        Cars CRUD API
@@ -46,98 +56,355 @@ import (
 func main() {
 	cfg := config.Load()
 
+	appLogger := logger.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	slog.SetDefault(appLogger)
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
+	ctx = logger.WithContext(ctx, appLogger)
 
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.PostgresHost, cfg.PostgresPort, cfg.PostgresUser, cfg.PostgresPass, cfg.PostgresDB,
 	)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError maps unique violations to gorm.ErrDuplicatedKey.
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
-		log.Fatalf("failed to connect to postgres: %v", err)
+		fatal("failed to connect to postgres", err)
 	}
-	if err := db.AutoMigrate(&domain.Car{}); err != nil {
-		log.Fatalf("failed to migrate: %v", err)
+	if err := db.AutoMigrate(&domain.Car{}, &domain.CarAudit{}, &domain.APIKey{}, &domain.User{}); err != nil {
+		fatal("failed to migrate", err)
 	}
-	log.Println("postgres connected and migrated")
+	slog.Info("postgres connected and migrated")
 
 	redisCache, err := cache.NewRedisCache(cfg)
 	if err != nil {
-		log.Fatalf("failed to connect to redis: %v", err)
+		fatal("failed to connect to redis", err)
 	}
-	log.Println("redis connected")
+	slog.Info("redis connected")
 
 	mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
 	if err != nil {
-		log.Fatalf("failed to connect to mongo: %v", err)
+		fatal("failed to connect to mongo", err)
 	}
 	defer mongoClient.Disconnect(ctx)
-	log.Println("mongo connected")
+	slog.Info("mongo connected")
 
-	producer := queue.NewLogProducer(cfg)
+	producer, err := queue.NewLogProducer(cfg)
+	if err != nil {
+		fatal("failed to create kafka producer", err)
+	}
 	defer producer.Close()
 
 	consumer := queue.NewLogConsumer(cfg, mongoClient)
 	consumer.Start(ctx)
 	defer consumer.Close()
-	log.Println("kafka producer and consumer started")
+
+	tail := queue.NewLogTail(cfg)
+	tail.Start(ctx)
+	defer tail.Close()
+	slog.Info("kafka producer and consumer started",
+		slog.String("topic", cfg.KafkaTopic),
+		slog.String("encoding", cfg.KafkaEncoding),
+	)
 
 	logCollection := mongoClient.Database(cfg.MongoDB).Collection(cfg.MongoCollection)
+	if err := mongoRepo.EnsureLogIndexes(ctx, logCollection); err != nil {
+		fatal("failed to create log indexes", err)
+	}
+	retention := time.Duration(cfg.LogRetentionDays) * 24 * time.Hour
+	if err := mongoRepo.EnsureLogRetention(ctx, logCollection, retention); err != nil {
+		fatal("failed to apply log retention", err)
+	}
 
 	carRepo := pgRepo.NewCarRepository(db)
 	logRepo := mongoRepo.NewLogRepository(logCollection)
-	carUsecase := usecase.NewCarUsecase(carRepo, redisCache)
+	carAuditRepo := pgRepo.NewCarAuditRepository(db)
+	carUsecase := usecase.NewCarUsecase(carRepo, carAuditRepo, pgRepo.NewTransactor(db), redisCache)
+	apiKeyUsecase := usecase.NewAPIKeyUsecase(pgRepo.NewAPIKeyRepository(db), cfg.APIKey)
+	userUsecase := usecase.NewUserUsecase(pgRepo.NewUserRepository(db))
+
+	if cfg.LogArchiveDir != "" {
+		archiveAfter := time.Duration(cfg.LogArchiveAfterDays) * 24 * time.Hour
+		if retention <= 0 || archiveAfter >= retention {
+			fatal("invalid log archive settings", errors.New("LOG_ARCHIVE_AFTER_DAYS must be lower than LOG_RETENTION_DAYS"))
+		}
+		archiver := usecase.NewLogArchiver(logRepo, cfg.LogArchiveDir, archiveAfter, retention)
+		go archiver.Run(ctx, cfg.LogArchiveInterval)
+		slog.Info("log archiving enabled", slog.String("dir", cfg.LogArchiveDir))
+	}
+
+	signingKeys := jwtkeys.NewHMAC(cfg.JWTSecret)
+	if cfg.JWTKeysDir != "" {
+		if signingKeys, err = jwtkeys.LoadDir(cfg.JWTKeysDir, cfg.JWTSigningKeyID); err != nil {
+			fatal("failed to load jwt signing keys", err)
+		}
+		slog.Info("jwt signing keys loaded", slog.String("dir", cfg.JWTKeysDir))
+	}
+
+	var externalIssuers *usecase.ExternalIssuers
+	if len(cfg.OIDCIssuers) > 0 {
+		if cfg.OIDCAudience == "" {
+			fatal("invalid oidc settings", errors.New("OIDC_AUDIENCE is required when OIDC_ISSUERS is set"))
+		}
+		for value, scopes := range cfg.OIDCScopeMap {
+			for _, scope := range scopes {
+				if !domain.ValidScope(scope) {
+					fatal("invalid OIDC_SCOPE_MAP", fmt.Errorf("unknown scope %q for %q", scope, value))
+				}
+			}
+		}
+		externalIssuers = usecase.NewExternalIssuers(
+			cfg.OIDCIssuers,
+			cfg.OIDCAudience,
+			cfg.JWTLeeway,
+			cfg.OIDCJWKSCacheTTL,
+			cfg.OIDCScopeClaims,
+			cfg.OIDCScopeMap,
+		)
+		slog.Info("trusting external token issuers", slog.Any("issuers", cfg.OIDCIssuers))
+	}
+
+	tokenUsecase := usecase.NewTokenUsecase(
+		redisRepo.NewTokenStore(redisCache.Client),
+		usecase.NewSubjectResolver(apiKeyUsecase, userUsecase),
+		signingKeys,
+		externalIssuers,
+		usecase.TokenOptions{
+			Issuer:     cfg.JWTIssuer,
+			Audience:   cfg.JWTAudience,
+			Leeway:     cfg.JWTLeeway,
+			AccessTTL:  cfg.JWTAccessTTL,
+			RefreshTTL: cfg.JWTRefreshTTL,
+		},
+	)
+
+	groupLimits, err := parseRateLimits(cfg.RateLimits)
+	if err != nil {
+		fatal("invalid RATE_LIMITS", err)
+	}
+	for group := range groupLimits {
+		if !slices.Contains(rateLimitGroups, group) {
+			fatal("invalid RATE_LIMITS", fmt.Errorf("unknown route group %q, want one of %v", group, rateLimitGroups))
+		}
+	}
+	keyLimits, err := parseRateLimits(cfg.RateLimitKeys)
+	if err != nil {
+		fatal("invalid RATE_LIMIT_KEYS", err)
+	}
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		fatal("invalid TRUSTED_PROXIES", err)
+	}
+	rateLimiter := middleware.NewRateLimiter(
+		redisRepo.NewRateLimiter(redisCache.Client),
+		memoryRepo.NewRateLimiter(),
+		keyLimits,
+	)
+	rateLimit := func(group string) func(http.Handler) http.Handler {
+		limit, ok := groupLimits[group]
+		if !cfg.RateLimitEnabled || !ok {
+			return func(next http.Handler) http.Handler { return next }
+		}
+		return rateLimiter.Limit(group, limit)
+	}
+
+	if cfg.LockoutMaxFailures > 0 && (cfg.LockoutWindow <= 0 || cfg.LockoutDuration <= 0 || cfg.LockoutMaxDuration < cfg.LockoutDuration) {
+		fatal("invalid lockout settings", errors.New("LOCKOUT_WINDOW and LOCKOUT_DURATION must be positive and LOCKOUT_MAX_DURATION at least LOCKOUT_DURATION"))
+	}
+	lockoutUsecase := usecase.NewLockoutUsecase(
+		redisRepo.NewLockoutStore(redisCache.Client),
+		producer,
+		domain.LockoutPolicy{
+			MaxFailures: cfg.LockoutMaxFailures,
+			Window:      cfg.LockoutWindow,
+			Duration:    cfg.LockoutDuration,
+			MaxDuration: cfg.LockoutMaxDuration,
+			ResetAfter:  cfg.LockoutResetAfter,
+		},
+	)
+
+	var (
+		tlsConfig   *tls.Config
+		clientCerts middleware.CertMapper
+	)
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+			fatal("invalid tls settings", errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+		}
+		if cfg.TLSReloadInterval <= 0 {
+			fatal("invalid tls settings", errors.New("TLS_RELOAD_INTERVAL must be positive"))
+		}
+		clientAuth, err := parseClientAuth(cfg.TLSClientAuth)
+		if err != nil {
+			fatal("invalid TLS_CLIENT_AUTH", err)
+		}
+		reloader, err := certs.New(cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile)
+		if err != nil {
+			fatal("failed to load tls certificate", err)
+		}
+		go reloader.Watch(ctx, cfg.TLSReloadInterval)
+		tlsConfig = reloader.ServerConfig(clientAuth)
+	} else if cfg.TLSClientCAFile != "" {
+		fatal("invalid tls settings", errors.New("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE"))
+	}
+	if len(cfg.TLSClientScopes) > 0 {
+		if cfg.TLSClientCAFile == "" {
+			fatal("invalid tls settings", errors.New("TLS_CLIENT_SCOPES requires TLS_CLIENT_CA_FILE"))
+		}
+		for name, scopes := range cfg.TLSClientScopes {
+			for _, scope := range scopes {
+				if !domain.ValidScope(scope) {
+					fatal("invalid TLS_CLIENT_SCOPES", fmt.Errorf("unknown scope %q for %q", scope, name))
+				}
+			}
+		}
+		clientCerts = usecase.NewClientCerts(cfg.TLSClientScopes)
+	}
 
-	authHandler := handler.NewAuthHandler(cfg.APIKey, cfg.JWTSecret)
+	authHandler := handler.NewAuthHandler(apiKeyUsecase, userUsecase, tokenUsecase, lockoutUsecase)
+	userHandler := handler.NewUserHandler(userUsecase)
+	lockoutHandler := handler.NewLockoutHandler(lockoutUsecase)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyUsecase)
 	carHandler := handler.NewCarHandler(carUsecase)
-	logHandler := handler.NewLogHandler(logRepo)
+	logHandler := handler.NewLogHandler(logRepo, tail)
+	adminHandler := handler.NewAdminHandler(producer, consumer)
+	healthHandler := handler.NewHealthHandler(map[string]handler.HealthCheck{
+		"log_consumer": consumer.Health,
+	})
+
+	expvar.Publish("log_producer", expvar.Func(func() any { return producer.Stats() }))
+	expvar.Publish("log_consumer", expvar.Func(func() any { return consumer.Stats() }))
 
 	r := chi.NewRouter()
 
 	r.Use(chimiddleware.RequestID)
-	r.Use(chimiddleware.RealIP)
+	r.Use(middleware.ContextLogger(appLogger))
+	r.Use(middleware.RealIP(trustedProxies))
 	r.Use(chimiddleware.Recoverer)
-	r.Use(chimiddleware.Timeout(30 * time.Second))
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
-	r.Use(middleware.RequestLogger(producer))
+	r.Use(middleware.RequestLogger(producer, middleware.LogOptions{
+		CaptureBodies: cfg.LogCaptureBodies,
+		MaxBodyBytes:  cfg.LogBodyMaxBytes,
+		RedactFields:  cfg.LogRedactFields,
+		RedactHeaders: cfg.LogRedactHeaders,
 
-	r.Get("/swagger/*", httpSwagger.WrapHandler)
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok"}`))
-	})
+		ExcludePaths:     cfg.LogExcludePaths,
+		SampleRate:       cfg.LogSampleRate,
+		RouteSampleRates: cfg.LogRouteSampleRates,
+		AlwaysLogErrors:  cfg.LogAlwaysLogErrors,
+		SlowThreshold:    cfg.LogSlowThreshold,
+	}))
 
-	authHandler.RegisterRoutes(r)
+	r.Group(func(r chi.Router) {
+		r.Use(chimiddleware.Timeout(30 * time.Second))
+
+		r.Get("/swagger/*", httpSwagger.WrapHandler)
+		healthHandler.RegisterRoutes(r)
+
+		r.Group(func(r chi.Router) {
+			r.Use(rateLimit("auth"))
+			authHandler.RegisterRoutes(r)
+		})
+		r.Group(func(r chi.Router) {
+			r.Use(rateLimit("jwks"))
+			authHandler.RegisterKeyRoutes(r)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.JWTAuth(tokenUsecase, clientCerts))
+			r.Group(func(r chi.Router) {
+				r.Use(rateLimit("cars"))
+				carHandler.RegisterRoutes(r)
+			})
+			r.Group(func(r chi.Router) {
+				r.Use(rateLimit("logs"))
+				logHandler.RegisterRoutes(r)
+			})
+			r.Group(func(r chi.Router) {
+				r.Use(rateLimit("admin"))
+				adminHandler.RegisterRoutes(r)
+				apiKeyHandler.RegisterRoutes(r)
+				lockoutHandler.RegisterRoutes(r)
+				userHandler.RegisterRoutes(r)
+			})
+		})
+	})
 
+	// Streaming and export routes can outlive any fixed deadline, so they skip the request timeout.
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.JWTAuth(cfg.JWTSecret))
-		carHandler.RegisterRoutes(r)
-		logHandler.RegisterRoutes(r)
+		r.Use(middleware.JWTAuth(tokenUsecase, clientCerts))
+		r.Use(rateLimit("logs"))
+		logHandler.RegisterStreamRoutes(r)
 	})
 
 	srv := &http.Server{
-		Addr:    ":" + cfg.AppPort,
-		Handler: r,
+		Addr:      ":" + cfg.AppPort,
+		Handler:   r,
+		TLSConfig: tlsConfig,
+		ErrorLog:  slog.NewLogLogger(appLogger.Handler(), slog.LevelError),
 	}
 
 	go func() {
-		log.Printf("server listening on :%s", cfg.AppPort)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("server error: %v", err)
+		slog.Info("server listening", slog.String("addr", srv.Addr), slog.Bool("tls", tlsConfig != nil))
+		var err error
+		if tlsConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("server error", err)
 		}
 	}()
 
 	<-ctx.Done()
-	log.Println("shutting down...")
+	slog.Info("shutting down")
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	srv.Shutdown(shutdownCtx)
 }
+
+// rateLimitGroups are the route groups RATE_LIMITS can configure.
+var rateLimitGroups = []string{"auth", "jwks", "cars", "logs", "admin"}
+
+// parseRateLimits parses each "<requests>/<window>" value of limits.
+func parseRateLimits(limits map[string]string) (map[string]domain.RateLimit, error) {
+	parsed := make(map[string]domain.RateLimit, len(limits))
+	for name, s := range limits {
+		limit, err := domain.ParseRateLimit(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed[name] = limit
+	}
+	return parsed, nil
+}
+
+// parseClientAuth maps TLS_CLIENT_AUTH onto the verification applied to
+// client certificates. Certificates are only requested when a client CA
+// bundle is configured.
+func parseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown mode %q, want optional or require", mode)
+	}
+}
+
+// fatal logs err and exits, like log.Fatal; deferred calls do not run.
+func fatal(msg string, err error) {
+	slog.Error(msg, logger.Err(err))
+	os.Exit(1)
+}
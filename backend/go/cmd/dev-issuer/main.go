@@ -0,0 +1,78 @@
+// Command dev-issuer is a minimal OpenID Connect issuer for local testing of
+// OIDC_ISSUERS. It serves discovery and a JWKS for a key generated at start
+// and mints tokens with whatever claims are posted to /token.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/gino/cars-crud/pkg/jwtkeys"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	issuer := flag.String("issuer", "http://localhost:9000", "issuer URL placed in discovery and tokens")
+	ttl := flag.Duration("ttl", time.Hour, "lifetime of minted tokens")
+	flag.Parse()
+
+	keys, err := jwtkeys.Generate(time.Now().UTC().Format("20060102T150405"))
+	if err != nil {
+		slog.Error("failed to generate key", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                *issuer,
+			"jwks_uri":                              strings.TrimSuffix(*issuer, "/") + "/jwks.json",
+			"id_token_signing_alg_values_supported": []string{"EdDSA"},
+		})
+	})
+	mux.HandleFunc("GET /jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, keys.JWKS())
+	})
+	// POST /token signs the posted claims. iss, iat and exp are filled in
+	// unless the body sets them.
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		claims := jwt.MapClaims{}
+		if err := json.NewDecoder(r.Body).Decode(&claims); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid claims"})
+			return
+		}
+		now := time.Now()
+		defaults := map[string]interface{}{"iss": *issuer, "iat": now.Unix(), "exp": now.Add(*ttl).Unix()}
+		for k, v := range defaults {
+			if _, ok := claims[k]; !ok {
+				claims[k] = v
+			}
+		}
+
+		token, err := keys.Sign(claims)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"access_token": token, "token_type": "Bearer"})
+	})
+
+	slog.Info("dev issuer listening", slog.String("addr", *addr), slog.String("issuer", *issuer))
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		slog.Error("server failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
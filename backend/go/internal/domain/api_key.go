@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// BootstrapKeyName identifies the key configured through API_KEY, which has
+// no stored record.
+const BootstrapKeyName = "bootstrap"
+
+// APIKey is a managed client credential. Only the SHA-256 hash of the key is
+// stored; Prefix is the public part used to look it up.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Name       string     `json:"name" gorm:"not null;size:100" example:"billing-service"`
+	Owner      string     `json:"owner" gorm:"size:255" example:"billing-team@example.com"`
+	Prefix     string     `json:"prefix" gorm:"not null;size:32;uniqueIndex" example:"ck_3f9a1c2b7d4e"`
+	Hash       string     `json:"-" gorm:"not null;size:64"`
+	Scopes     []string   `json:"scopes" gorm:"type:jsonb;serializer:json" example:"cars:read,cars:write"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Revoked    bool       `json:"revoked" gorm:"not null;default:false"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// Subject is the token subject for the key: its ID, or the bootstrap name.
+func (k *APIKey) Subject() string {
+	if k.ID == uuid.Nil {
+		return BootstrapKeyName
+	}
+	return k.ID.String()
+}
+
+func (k *APIKey) Principal() Principal {
+	return Principal{Subject: k.Subject(), Name: k.Name, Scopes: k.Scopes}
+}
+
+// Active reports whether the key may still be used at t.
+func (k *APIKey) Active(t time.Time) bool {
+	return !k.Revoked && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" example:"billing-service"`
+	Owner     string     `json:"owner" example:"billing-team@example.com"`
+	Scopes    []string   `json:"scopes" example:"cars:read,cars:write"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2026-01-01T00:00:00Z"`
+}
+
+// CreateAPIKeyResponse carries the plaintext key, which is only ever
+// returned once, at creation.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key" example:"ck_3f9a1c2b7d4e_9b2f..."`
+}
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+// CarAudit records one change to a car. Entries are kept after the car is
+// deleted so its full history stays available.
+type CarAudit struct {
+	ID        uuid.UUID    `json:"id" gorm:"type:uuid;primaryKey"`
+	CarID     uuid.UUID    `json:"car_id" gorm:"type:uuid;not null;index:idx_car_audits_car_time,priority:1"`
+	Action    AuditAction  `json:"action" gorm:"not null;size:20" example:"update"`
+	Actor     string       `json:"actor" gorm:"not null;size:255" example:"api-key"`
+	Changes   AuditChanges `json:"changes" gorm:"type:jsonb;not null" swaggertype:"object"`
+	CreatedAt time.Time    `json:"created_at" gorm:"index:idx_car_audits_car_time,priority:2"`
+}
+
+// FieldChange holds the old and new value of a field; From is null on
+// create and restore, To is null on delete.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditChanges maps JSON field names to their change and is stored as jsonb.
+type AuditChanges map[string]FieldChange
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(c)
+	return string(data), err
+}
+
+func (c *AuditChanges) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*c = nil
+		return nil
+	default:
+		return errors.New("unsupported type for AuditChanges")
+	}
+	return json.Unmarshal(data, c)
+}
+
+func (a *CarAudit) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// DiffCars returns the audited fields that differ between before and after.
+// A nil side stands for a car that does not exist (yet or anymore).
+func DiffCars(before, after *Car) AuditChanges {
+	changes := AuditChanges{}
+	for _, f := range carAuditFields {
+		var from, to interface{}
+		if before != nil {
+			from = f.get(before)
+		}
+		if after != nil {
+			to = f.get(after)
+		}
+		if from != to {
+			changes[f.name] = FieldChange{From: from, To: to}
+		}
+	}
+	return changes
+}
+
+var carAuditFields = []struct {
+	name string
+	get  func(*Car) interface{}
+}{
+	{"brand", func(c *Car) interface{} { return c.Brand }},
+	{"model", func(c *Car) interface{} { return c.Model }},
+	{"year", func(c *Car) interface{} { return c.Year }},
+	{"color", func(c *Car) interface{} { return c.Color }},
+	{"price", func(c *Car) interface{} { return c.Price }},
+}
+
+// ActorFromContext returns the subject of the principal performing the
+// request, or "anonymous".
+func ActorFromContext(ctx context.Context) string {
+	if p, ok := PrincipalFromContext(ctx); ok && p.Subject != "" {
+		return p.Subject
+	}
+	return "anonymous"
+}
@@ -1,9 +1,80 @@
 package domain
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidToken        = errors.New("invalid or expired token")
+	ErrTokenRevoked        = errors.New("token has been revoked")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when a rotated refresh token is
+	// presented again, which revokes the whole session.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
 type ValidateRequest struct {
 	APIKey string `json:"api_key" example:"my-api-key-12345"`
 }
 
+// ValidateResponse is returned whenever tokens are issued.
 type ValidateResponse struct {
-	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	RefreshToken string `json:"refresh_token" example:"rt_4f1c9e..."`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" example:"rt_4f1c9e..."`
+}
+
+// RevokeRequest takes either an access token or a refresh token.
+type RevokeRequest struct {
+	Token string `json:"token" example:"rt_4f1c9e..."`
+}
+
+// CertSubjectPrefix marks principals authenticated by a client certificate
+// rather than a token.
+const CertSubjectPrefix = "cert:"
+
+// Principal is the identity tokens are issued for. For API keys the Subject
+// is the key ID, for users "user:<id>" and for client certificates
+// "cert:<common name>". Roles are only set for users; their scopes are
+// derived from them. Issuer is only set on principals read from a token.
+type Principal struct {
+	Subject string   `json:"subject"`
+	Name    string   `json:"name"`
+	Scopes  []string `json:"scopes"`
+	Roles   []string `json:"roles,omitempty"`
+	Issuer  string   `json:"issuer,omitempty"`
+}
+
+// HasScope reports whether p was granted scope. It is false for a nil p.
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && HasScope(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal set by WithPrincipal.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// RefreshSession is stored for each outstanding refresh token. Every token
+// obtained by rotating it shares its Session ID, so one reuse or revocation
+// ends them all.
+type RefreshSession struct {
+	Session   string    `json:"session"`
+	Subject   string    `json:"subject"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
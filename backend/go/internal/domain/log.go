@@ -1,17 +1,88 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type RequestLog struct {
-	Method     string    `json:"method" bson:"method"`
-	Path       string    `json:"path" bson:"path"`
-	StatusCode int       `json:"status_code" bson:"status_code"`
-	Duration   int64     `json:"duration_ms" bson:"duration_ms"`
-	IP         string    `json:"ip" bson:"ip"`
-	UserAgent  string    `json:"user_agent" bson:"user_agent"`
-	Timestamp  time.Time `json:"timestamp" bson:"timestamp"`
+	Method        string    `json:"method" bson:"method"`
+	Path          string    `json:"path" bson:"path"`
+	Route         string    `json:"route,omitempty" bson:"route,omitempty"`
+	Query         string    `json:"query,omitempty" bson:"query,omitempty"`
+	StatusCode    int       `json:"status_code" bson:"status_code"`
+	Duration      int64     `json:"duration_ms" bson:"duration_ms"`
+	ResponseBytes int64     `json:"response_bytes" bson:"response_bytes"`
+	IP            string    `json:"ip" bson:"ip"`
+	UserAgent     string    `json:"user_agent" bson:"user_agent"`
+	RequestID     string    `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	Principal     string    `json:"principal,omitempty" bson:"principal,omitempty"`
+	Timestamp     time.Time `json:"timestamp" bson:"timestamp"`
+
+	RequestHeaders        map[string]string `json:"request_headers,omitempty" bson:"request_headers,omitempty"`
+	RequestBody           string            `json:"request_body,omitempty" bson:"request_body,omitempty"`
+	RequestBodyTruncated  bool              `json:"request_body_truncated,omitempty" bson:"request_body_truncated,omitempty"`
+	ResponseBody          string            `json:"response_body,omitempty" bson:"response_body,omitempty"`
+	ResponseBodyTruncated bool              `json:"response_body_truncated,omitempty" bson:"response_body_truncated,omitempty"`
 }
 
 func (r *RequestLog) BeforeInsert() {
 	r.Timestamp = time.Now()
 }
+
+type LogFilter struct {
+	Method        string
+	Route         string
+	Path          string
+	PathPrefix    string
+	StatusCode    int
+	StatusMin     int
+	StatusMax     int
+	MinDurationMs *int64
+	MaxDurationMs *int64
+	IP            string
+	UserAgent     string
+	Principal     string
+	From          *time.Time
+	To            *time.Time
+}
+
+// Matches reports whether an entry satisfies the filter, for filtering logs
+// that do not come from the repository such as the live stream.
+func (f LogFilter) Matches(l RequestLog) bool {
+	switch {
+	case f.Method != "" && l.Method != f.Method,
+		f.Route != "" && l.Route != f.Route,
+		f.Path != "" && l.Path != f.Path,
+		f.PathPrefix != "" && !strings.HasPrefix(l.Path, f.PathPrefix),
+		f.StatusCode != 0 && l.StatusCode != f.StatusCode,
+		f.StatusMin != 0 && l.StatusCode < f.StatusMin,
+		f.StatusMax != 0 && l.StatusCode > f.StatusMax,
+		f.MinDurationMs != nil && l.Duration < *f.MinDurationMs,
+		f.MaxDurationMs != nil && l.Duration > *f.MaxDurationMs,
+		f.IP != "" && l.IP != f.IP,
+		f.UserAgent != "" && !strings.Contains(strings.ToLower(l.UserAgent), strings.ToLower(f.UserAgent)),
+		f.Principal != "" && l.Principal != f.Principal,
+		f.From != nil && l.Timestamp.Before(*f.From),
+		f.To != nil && !l.Timestamp.Before(*f.To):
+		return false
+	}
+	return true
+}
+
+type LogStatsQuery struct {
+	Filter   LogFilter
+	Interval string
+}
+
+type LogStatsBucket struct {
+	Bucket    time.Time `json:"bucket" bson:"bucket" example:"2026-02-19T15:00:00Z"`
+	Method    string    `json:"method" bson:"method" example:"GET"`
+	Route     string    `json:"route" bson:"route" example:"/api/v1/cars"`
+	Count     int64     `json:"count" bson:"count" example:"1200"`
+	Errors    int64     `json:"errors" bson:"errors" example:"6"`
+	ErrorRate float64   `json:"error_rate" bson:"error_rate" example:"0.005"`
+	P50       float64   `json:"p50_ms" bson:"p50" example:"4"`
+	P95       float64   `json:"p95_ms" bson:"p95" example:"18"`
+	P99       float64   `json:"p99_ms" bson:"p99" example:"42"`
+}
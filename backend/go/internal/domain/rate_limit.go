@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit allows Requests per Window. Capacity refills evenly over the
+// window (GCRA), so bursts up to Requests are allowed but a client can never
+// exceed the average rate for long.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// MinRateLimitInterval is the shortest time a limit may take to earn back
+// one request. Shorter intervals round to zero, which the GCRA cannot use
+// and the Redis script (counting in microseconds) would treat as no limit.
+const MinRateLimitInterval = time.Microsecond
+
+// ParseRateLimit parses limits written as "<requests>/<window>", e.g.
+// "100/1m".
+func ParseRateLimit(s string) (RateLimit, error) {
+	requests, window, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q: want <requests>/<window>", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: invalid request count", s)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: invalid window", s)
+	}
+	limit := RateLimit{Requests: n, Window: d}
+	if limit.Interval() < MinRateLimitInterval {
+		return RateLimit{}, fmt.Errorf("rate limit %q: more than one request per %s", s, MinRateLimitInterval)
+	}
+	return limit, nil
+}
+
+// Interval is the time it takes to earn back one request.
+func (l RateLimit) Interval() time.Duration {
+	return l.Window / time.Duration(l.Requests)
+}
+
+// RateLimitResult is the outcome of counting one request. Reset is the time
+// until the full limit is available again; RetryAfter is only set when the
+// request was denied.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// EvaluateRateLimit applies one request at now to a client whose theoretical
+// arrival time is tat, returning the outcome and the new tat to store. A
+// zero tat stands for a client with no recent requests.
+func EvaluateRateLimit(limit RateLimit, tat, now time.Time) (RateLimitResult, time.Time) {
+	if tat.Before(now) {
+		tat = now
+	}
+	interval := limit.Interval()
+	next := tat.Add(interval)
+
+	if allowAt := next.Add(-limit.Window); allowAt.After(now) {
+		return RateLimitResult{
+			Limit:      limit.Requests,
+			Reset:      tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}, tat
+	}
+	return RateLimitResult{
+		Allowed:   true,
+		Limit:     limit.Requests,
+		Remaining: int((limit.Window - next.Sub(now)) / interval),
+		Reset:     next.Sub(now),
+	}, next
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    RateLimit
+		wantErr bool
+	}{
+		{in: "100/1m", want: RateLimit{Requests: 100, Window: time.Minute}},
+		{in: " 10/1s ", want: RateLimit{Requests: 10, Window: time.Second}},
+		{in: "1000000/1s", want: RateLimit{Requests: 1000000, Window: time.Second}},
+		{in: "1/1us", want: RateLimit{Requests: 1, Window: time.Microsecond}},
+		{in: "2000/1us", wantErr: true},
+		{in: "2/1us", wantErr: true},
+		{in: "1000001/1s", wantErr: true},
+		{in: "100", wantErr: true},
+		{in: "0/1m", wantErr: true},
+		{in: "-5/1m", wantErr: true},
+		{in: "x/1m", wantErr: true},
+		{in: "100/0s", wantErr: true},
+		{in: "100/-1m", wantErr: true},
+		{in: "100/soon", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseRateLimit(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRateLimit(%q) = %+v, want an error", tc.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRateLimit(%q): %v", tc.in, err)
+			}
+			if got != tc.want {
+				t.Errorf("ParseRateLimit(%q) = %+v, want %+v", tc.in, got, tc.want)
+			}
+			if got.Interval() < MinRateLimitInterval {
+				t.Errorf("Interval() = %s, want at least %s", got.Interval(), MinRateLimitInterval)
+			}
+		})
+	}
+}
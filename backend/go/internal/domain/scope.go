@@ -0,0 +1,22 @@
+package domain
+
+import "slices"
+
+const (
+	ScopeCarsRead  = "cars:read"
+	ScopeCarsWrite = "cars:write"
+	ScopeLogsRead  = "logs:read"
+	// ScopeAdmin grants every other scope as well.
+	ScopeAdmin = "admin"
+)
+
+var Scopes = []string{ScopeCarsRead, ScopeCarsWrite, ScopeLogsRead, ScopeAdmin}
+
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// HasScope reports whether granted satisfies want.
+func HasScope(granted []string, want string) bool {
+	return slices.Contains(granted, want) || slices.Contains(granted, ScopeAdmin)
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+const (
+	SecurityEventAuthFailure = "auth_failure"
+	SecurityEventLockout     = "lockout"
+)
+
+// SecurityEvent records a failed authentication attempt or a lockout it
+// caused. Events travel through the request log pipeline and are stored
+// apart from request logs.
+type SecurityEvent struct {
+	Type      string    `json:"type" bson:"type"`
+	Subject   string    `json:"subject,omitempty" bson:"subject,omitempty"`
+	IP        string    `json:"ip" bson:"ip"`
+	KeyPrefix string    `json:"key_prefix,omitempty" bson:"key_prefix,omitempty"`
+	Username  string    `json:"username,omitempty" bson:"username,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
+	RequestID string    `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	Reason    string    `json:"reason,omitempty" bson:"reason,omitempty"`
+	Failures  int       `json:"failures,omitempty" bson:"failures,omitempty"`
+	Level     int       `json:"level,omitempty" bson:"level,omitempty"`
+	LockedFor int64     `json:"locked_for_seconds,omitempty" bson:"locked_for_seconds,omitempty"`
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+}
+
+// AuthAttempt describes where an API key validation or password login came
+// from. Only one of APIKey and Username is set.
+type AuthAttempt struct {
+	IP        string
+	APIKey    string
+	Username  string
+	UserAgent string
+	RequestID string
+}
+
+// LockoutPolicy locks a subject out after MaxFailures failed attempts within
+// Window. The first lockout lasts Duration and each further one twice as
+// long as the last, up to MaxDuration. A subject that stays clean for
+// ResetAfter starts over at Duration. MaxFailures 0 disables lockouts.
+type LockoutPolicy struct {
+	MaxFailures int
+	Window      time.Duration
+	Duration    time.Duration
+	MaxDuration time.Duration
+	ResetAfter  time.Duration
+}
+
+// Lockout is an active lockout. Subjects are "ip:<address>",
+// "key:<key prefix>", "key:bootstrap" or "login:<username>".
+type Lockout struct {
+	Subject   string    `json:"subject" example:"ip:203.0.113.7"`
+	Level     int       `json:"level" example:"2"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+type ProducerStats struct {
+	DropPolicy     string     `json:"drop_policy" example:"drop-oldest"`
+	BufferCapacity int        `json:"buffer_capacity" example:"1000"`
+	Buffered       int        `json:"buffered" example:"3"`
+	Enqueued       int64      `json:"enqueued" example:"15230"`
+	Delivered      int64      `json:"delivered" example:"15200"`
+	Failed         int64      `json:"failed" example:"12"`
+	Dropped        int64      `json:"dropped" example:"15"`
+	Spooled        int64      `json:"spooled" example:"12"`
+	Replayed       int64      `json:"replayed" example:"12"`
+	SpoolBytes     int64      `json:"spool_bytes" example:"0"`
+	SpoolDropped   int64      `json:"spool_dropped" example:"0"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+}
+
+type ConsumerStats struct {
+	Name               string     `json:"name" example:"log-consumer"`
+	Topic              string     `json:"topic" example:"car-api-logs"`
+	GroupID            string     `json:"group_id" example:"log-consumer-group"`
+	Processed          int64      `json:"processed" example:"15200"`
+	Errors             int64      `json:"errors" example:"2"`
+	Lag                int64      `json:"lag" example:"0"`
+	MaxLag             int64      `json:"max_lag" example:"1000"`
+	InsertLatencyAvgMs float64    `json:"insert_latency_avg_ms" example:"1.8"`
+	InsertLatencyMaxMs float64    `json:"insert_latency_max_ms" example:"42.1"`
+	LastMessageAt      *time.Time `json:"last_message_at,omitempty"`
+	Healthy            bool       `json:"healthy" example:"true"`
+}
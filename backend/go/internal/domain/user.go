@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUsernameTaken      = errors.New("username already taken")
+)
+
+const (
+	RoleViewer  = "viewer"
+	RoleEditor  = "editor"
+	RoleAuditor = "auditor"
+	RoleAdmin   = "admin"
+)
+
+// UserSubjectPrefix marks token subjects that are users rather than API
+// keys.
+const UserSubjectPrefix = "user:"
+
+// roleScopes maps each role onto the scopes it grants.
+var roleScopes = map[string][]string{
+	RoleViewer:  {ScopeCarsRead},
+	RoleEditor:  {ScopeCarsRead, ScopeCarsWrite},
+	RoleAuditor: {ScopeCarsRead, ScopeLogsRead},
+	RoleAdmin:   {ScopeAdmin},
+}
+
+var Roles = []string{RoleViewer, RoleEditor, RoleAuditor, RoleAdmin}
+
+func ValidRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
+
+// ScopesForRoles returns the sorted union of the scopes granted by roles.
+func ScopesForRoles(roles []string) []string {
+	var scopes []string
+	for _, role := range roles {
+		scopes = append(scopes, roleScopes[role]...)
+	}
+	slices.Sort(scopes)
+	return slices.Compact(scopes)
+}
+
+// User is a human operator who signs in with a password. Only the bcrypt
+// hash of the password is stored.
+type User struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Username     string     `json:"username" gorm:"not null;size:64;uniqueIndex" example:"alice"`
+	Name         string     `json:"name" gorm:"size:100" example:"Alice Example"`
+	PasswordHash string     `json:"-" gorm:"not null;size:100"`
+	Roles        []string   `json:"roles" gorm:"type:jsonb;serializer:json" example:"editor"`
+	Disabled     bool       `json:"disabled" gorm:"not null;default:false"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
+
+// Subject is the token subject for the user, "user:<id>".
+func (u *User) Subject() string {
+	return UserSubjectPrefix + u.ID.String()
+}
+
+func (u *User) Principal() Principal {
+	name := u.Name
+	if name == "" {
+		name = u.Username
+	}
+	return Principal{Subject: u.Subject(), Name: name, Scopes: ScopesForRoles(u.Roles), Roles: u.Roles}
+}
+
+// NormalizeUsername lowercases and trims a username so lookups are case
+// insensitive.
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+type LoginRequest struct {
+	Username string `json:"username" example:"alice"`
+	Password string `json:"password" example:"correct horse battery staple"`
+}
+
+type CreateUserRequest struct {
+	Username string   `json:"username" example:"alice"`
+	Name     string   `json:"name" example:"Alice Example"`
+	Password string   `json:"password" example:"correct horse battery staple"`
+	Roles    []string `json:"roles" example:"editor"`
+}
+
+// UpdateUserRequest changes only the fields that are set. Setting Password
+// resets the user's password.
+type UpdateUserRequest struct {
+	Name     *string   `json:"name,omitempty" example:"Alice Example"`
+	Password *string   `json:"password,omitempty" example:"correct horse battery staple"`
+	Roles    *[]string `json:"roles,omitempty" example:"viewer"`
+	Disabled *bool     `json:"disabled,omitempty" example:"false"`
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/middleware"
+)
+
+type ProducerStatsSource interface {
+	Stats() domain.ProducerStats
+}
+
+type ConsumerStatsSource interface {
+	Stats() domain.ConsumerStats
+}
+
+type AdminHandler struct {
+	producer  ProducerStatsSource
+	consumers []ConsumerStatsSource
+}
+
+func NewAdminHandler(producer ProducerStatsSource, consumers ...ConsumerStatsSource) *AdminHandler {
+	return &AdminHandler{producer: producer, consumers: consumers}
+}
+
+func (h *AdminHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.RequireScope(domain.ScopeAdmin))
+		r.Get("/producer", h.ProducerStats)
+		r.Get("/consumers", h.ConsumerStats)
+		r.Handle("/metrics", expvar.Handler())
+	})
+}
+
+// ProducerStats godoc
+// @Summary      Kafka producer delivery stats
+// @Description  Returns buffer usage and delivered/failed/dropped counters for the request log producer
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  SuccessResponse{data=domain.ProducerStats}
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Router       /admin/producer [get]
+func (h *AdminHandler) ProducerStats(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: h.producer.Stats()})
+}
+
+// ConsumerStats godoc
+// @Summary      Kafka consumer throughput and lag
+// @Description  Returns processed/error counters, Mongo insert latency and reader lag for each Kafka consumer
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  SuccessResponse{data=[]domain.ConsumerStats}
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Router       /admin/consumers [get]
+func (h *AdminHandler) ConsumerStats(w http.ResponseWriter, r *http.Request) {
+	stats := make([]domain.ConsumerStats, 0, len(h.consumers))
+	for _, c := range h.consumers {
+		stats = append(stats, c.Stats())
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: stats})
+}
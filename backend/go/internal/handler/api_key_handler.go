@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/middleware"
+	"github.com/gino/cars-crud/internal/usecase"
+)
+
+type APIKeyHandler struct {
+	usecase *usecase.APIKeyUsecase
+}
+
+func NewAPIKeyHandler(uc *usecase.APIKeyUsecase) *APIKeyHandler {
+	return &APIKeyHandler{usecase: uc}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin/api-keys", func(r chi.Router) {
+		r.Use(middleware.RequireScope(domain.ScopeAdmin))
+		r.Post("/", h.Create)
+		r.Get("/", h.GetAll)
+		r.Delete("/{id}", h.Revoke)
+	})
+}
+
+// Create godoc
+// @Summary      Create an API key
+// @Description  Creates a managed API key with the given scopes (cars:read, cars:write, logs:read, admin). The plaintext key is only returned in this response; only its hash is stored.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      domain.CreateAPIKeyRequest  true  "Key details"
+// @Success      201   {object}  SuccessResponse{data=domain.CreateAPIKeyResponse}
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      429   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /admin/api-keys [post]
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		respondError(w, http.StatusBadRequest, "scopes is required")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !domain.ValidScope(scope) {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown scope %q", scope))
+			return
+		}
+	}
+	req.Scopes = slices.Compact(slices.Sorted(slices.Values(req.Scopes)))
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		respondError(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	key, err := h.usecase.Create(r.Context(), req)
+	if err != nil {
+		respondServerError(w, r, "failed to create api key", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, SuccessResponse{Data: key})
+}
+
+// GetAll godoc
+// @Summary      List API keys
+// @Description  Get a paginated list of managed API keys, newest first. Keys are never returned in plaintext.
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        offset  query     int  false  "Offset"  default(0)
+// @Param        limit   query     int  false  "Limit"   default(20)
+// @Success      200     {object}  PaginatedResponse{data=[]domain.APIKey}
+// @Failure      401     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      429     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /admin/api-keys [get]
+func (h *APIKeyHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	keys, total, err := h.usecase.GetAll(r.Context(), offset, limit)
+	if err != nil {
+		respondServerError(w, r, "failed to list api keys", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, PaginatedResponse{
+		Data:   keys,
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+	})
+}
+
+// Revoke godoc
+// @Summary      Revoke an API key
+// @Description  Revokes a managed API key so it can no longer be exchanged for tokens. The record is kept for auditing.
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "API key ID (UUID)"
+// @Success      200  {object}  SuccessResponse{data=domain.APIKey}
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid api key id")
+		return
+	}
+
+	key, err := h.usecase.Revoke(r.Context(), id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(w, http.StatusNotFound, "api key not found")
+			return
+		}
+		respondServerError(w, r, "failed to revoke api key", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: key})
+}
@@ -1,34 +1,58 @@
 package handler
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/golang-jwt/jwt/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 
 	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/middleware"
+	"github.com/gino/cars-crud/internal/usecase"
 )
 
 type AuthHandler struct {
-	apiKey    string
-	jwtSecret string
+	keys     *usecase.APIKeyUsecase
+	users    *usecase.UserUsecase
+	tokens   *usecase.TokenUsecase
+	lockouts *usecase.LockoutUsecase
 }
 
-func NewAuthHandler(apiKey, jwtSecret string) *AuthHandler {
-	return &AuthHandler{apiKey: apiKey, jwtSecret: jwtSecret}
+func NewAuthHandler(
+	keys *usecase.APIKeyUsecase,
+	users *usecase.UserUsecase,
+	tokens *usecase.TokenUsecase,
+	lockouts *usecase.LockoutUsecase,
+) *AuthHandler {
+	return &AuthHandler{keys: keys, users: users, tokens: tokens, lockouts: lockouts}
 }
 
 func (h *AuthHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/auth", func(r chi.Router) {
 		r.Post("/validate", h.Validate)
+		r.Post("/login", h.Login)
+		r.Post("/refresh", h.Refresh)
+		r.Post("/revoke", h.Revoke)
 	})
 }
 
+// RegisterKeyRoutes registers the JWKS. It is kept apart from the /auth
+// routes so verifiers polling it do not share the strict login limit.
+func (h *AuthHandler) RegisterKeyRoutes(r chi.Router) {
+	r.Get("/.well-known/jwks.json", h.JWKS)
+}
+
 // Validate godoc
 // @Summary      Validate API Key
-// @Description  Validates an API key and returns a JWT token
+// @Description  Validates an API key and returns a short-lived JWT access token and a refresh token. Repeated failures from one IP or for one key prefix lock further attempts out with 429.
 // @Tags         auth
 // @Accept       json
 // @Produce      json
@@ -36,6 +60,7 @@ func (h *AuthHandler) RegisterRoutes(r chi.Router) {
 // @Success      200   {object}  SuccessResponse{data=domain.ValidateResponse}
 // @Failure      400   {object}  ErrorResponse
 // @Failure      401   {object}  ErrorResponse
+// @Failure      429   {object}  ErrorResponse
 // @Failure      500   {object}  ErrorResponse
 // @Router       /auth/validate [post]
 func (h *AuthHandler) Validate(w http.ResponseWriter, r *http.Request) {
@@ -50,25 +75,209 @@ func (h *AuthHandler) Validate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.APIKey != h.apiKey {
-		respondError(w, http.StatusUnauthorized, "invalid api key")
+	attempt := domain.AuthAttempt{
+		IP:        middleware.ClientIP(r),
+		APIKey:    req.APIKey,
+		UserAgent: r.UserAgent(),
+		RequestID: chimiddleware.GetReqID(r.Context()),
+	}
+	if h.lockedOut(w, r, attempt) {
 		return
 	}
 
-	claims := jwt.MapClaims{
-		"iss": "cars-crud-api",
-		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
+	key, err := h.keys.Authenticate(r.Context(), req.APIKey)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidAPIKey) {
+			h.lockouts.Fail(r.Context(), attempt, "invalid api key")
+			respondError(w, http.StatusUnauthorized, "invalid api key")
+			return
+		}
+		respondServerError(w, r, "failed to validate api key", err)
+		return
 	}
+	h.lockouts.Succeed(r.Context(), attempt)
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(h.jwtSecret))
+	tokens, err := h.tokens.Issue(r.Context(), key.Principal())
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to generate token")
+		respondServerError(w, r, "failed to generate token", err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, SuccessResponse{
-		Data: domain.ValidateResponse{Token: signed},
-	})
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: tokens})
+}
+
+// Login godoc
+// @Summary      Sign in with a password
+// @Description  Checks a username and password and returns the same access and refresh tokens as /auth/validate. The access token carries the user's roles in a "roles" claim and the scopes they grant in "scope". Repeated failures from one IP or for one username lock further attempts out with 429.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      domain.LoginRequest  true  "Credentials"
+// @Success      200   {object}  SuccessResponse{data=domain.ValidateResponse}
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      429   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /auth/login [post]
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req domain.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(req.Username) == "" || req.Password == "" {
+		respondError(w, http.StatusBadRequest, "username and password are required")
+		return
+	}
+
+	attempt := domain.AuthAttempt{
+		IP:        middleware.ClientIP(r),
+		Username:  req.Username,
+		UserAgent: r.UserAgent(),
+		RequestID: chimiddleware.GetReqID(r.Context()),
+	}
+	if h.lockedOut(w, r, attempt) {
+		return
+	}
+
+	user, err := h.users.Authenticate(r.Context(), req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCredentials) {
+			h.lockouts.Fail(r.Context(), attempt, "invalid credentials")
+			respondError(w, http.StatusUnauthorized, "invalid username or password")
+			return
+		}
+		respondServerError(w, r, "failed to check credentials", err)
+		return
+	}
+	h.lockouts.Succeed(r.Context(), attempt)
+
+	tokens, err := h.tokens.Issue(r.Context(), user.Principal())
+	if err != nil {
+		respondServerError(w, r, "failed to generate token", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: tokens})
+}
+
+// lockedOut answers 429 and reports true when the attempt is locked out.
+func (h *AuthHandler) lockedOut(w http.ResponseWriter, r *http.Request, attempt domain.AuthAttempt) bool {
+	lockout, err := h.lockouts.Check(r.Context(), attempt)
+	if err != nil {
+		respondServerError(w, r, "failed to check lockout", err)
+		return true
+	}
+	if lockout == nil {
+		return false
+	}
+
+	retryAfter := math.Ceil(time.Until(lockout.ExpiresAt).Seconds())
+	w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter)))
+	respondError(w, http.StatusTooManyRequests, "too many failed attempts, try again later")
+	return true
+}
+
+// Refresh godoc
+// @Summary      Refresh tokens
+// @Description  Exchanges a refresh token for a new access token and refresh token. The presented refresh token is consumed; using it again revokes the whole session.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      domain.RefreshRequest  true  "Refresh token"
+// @Success      200   {object}  SuccessResponse{data=domain.ValidateResponse}
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      429   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /auth/refresh [post]
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req domain.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.RefreshToken == "" {
+		respondError(w, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	tokens, err := h.tokens.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidRefreshToken),
+			errors.Is(err, domain.ErrRefreshTokenReused),
+			errors.Is(err, domain.ErrInvalidAPIKey),
+			errors.Is(err, domain.ErrInvalidCredentials):
+			respondError(w, http.StatusUnauthorized, "invalid refresh token")
+		default:
+			respondServerError(w, r, "failed to refresh token", err)
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: tokens})
+}
+
+// Revoke godoc
+// @Summary      Revoke a token
+// @Description  Revokes an access token, or a refresh token together with every token of its session. Unknown or expired tokens are accepted without error.
+// @Tags         auth
+// @Accept       json
+// @Param        body  body      domain.RevokeRequest  true  "Access or refresh token"
+// @Success      204   "No Content"
+// @Failure      400   {object}  ErrorResponse
+// @Failure      429   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /auth/revoke [post]
+func (h *AuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	var req domain.RevokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	if err := h.tokens.Revoke(r.Context(), req.Token); err != nil {
+		respondServerError(w, r, "failed to revoke token", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// JWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys that verify access tokens, identified by kid. Empty when tokens are signed with the HS256 shared secret. Cacheable for 5 minutes; send the ETag back in If-None-Match to get 304 while the keys are unchanged.
+// @Tags         auth
+// @Produce      json
+// @Param        If-None-Match  header    string  false  "ETag of a cached copy"
+// @Success      200  {object}  jwtkeys.JWKS
+// @Success      304  "Keys unchanged"
+// @Failure      429  {object}  ErrorResponse
+// @Router       /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	body, err := json.Marshal(h.tokens.JWKS())
+	if err != nil {
+		respondServerError(w, r, "failed to encode key set", err)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("Cache-Control", "public, max-age=300, stale-while-revalidate=60")
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match == etag || match == "*" {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
 }
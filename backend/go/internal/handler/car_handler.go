@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/middleware"
 	"github.com/gino/cars-crud/internal/usecase"
 )
 
@@ -23,11 +24,20 @@ func NewCarHandler(uc *usecase.CarUsecase) *CarHandler {
 
 func (h *CarHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/api/v1/cars", func(r chi.Router) {
-		r.Post("/", h.Create)
-		r.Get("/", h.GetAll)
-		r.Get("/{id}", h.GetByID)
-		r.Put("/{id}", h.Update)
-		r.Delete("/{id}", h.Delete)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(domain.ScopeCarsRead))
+			r.Get("/", h.GetAll)
+			r.Get("/{id}", h.GetByID)
+			r.Get("/{id}/history", h.History)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireScope(domain.ScopeCarsWrite))
+			r.Post("/", h.Create)
+			r.Put("/{id}", h.Update)
+			r.Delete("/{id}", h.Delete)
+			r.Post("/{id}/restore", h.Restore)
+		})
 	})
 }
 
@@ -41,6 +51,8 @@ func (h *CarHandler) RegisterRoutes(r chi.Router) {
 // @Param        car  body      domain.CreateCarRequest  true  "Car data"
 // @Success      201  {object}  SuccessResponse{data=domain.Car}
 // @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
 // @Failure      500  {object}  ErrorResponse
 // @Router       /api/v1/cars [post]
 func (h *CarHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -57,7 +69,7 @@ func (h *CarHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	car, err := h.usecase.Create(r.Context(), req)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to create car")
+		respondServerError(w, r, "failed to create car", err)
 		return
 	}
 
@@ -73,6 +85,8 @@ func (h *CarHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Param        offset  query     int  false  "Offset"  default(0)
 // @Param        limit   query     int  false  "Limit"   default(10)
 // @Success      200     {object}  PaginatedResponse{data=[]domain.Car}
+// @Failure      403     {object}  ErrorResponse
+// @Failure      429     {object}  ErrorResponse
 // @Failure      500     {object}  ErrorResponse
 // @Router       /api/v1/cars [get]
 func (h *CarHandler) GetAll(w http.ResponseWriter, r *http.Request) {
@@ -88,7 +102,7 @@ func (h *CarHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 
 	cars, total, err := h.usecase.GetAll(r.Context(), offset, limit)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to list cars")
+		respondServerError(w, r, "failed to list cars", err)
 		return
 	}
 
@@ -109,7 +123,9 @@ func (h *CarHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Param        id   path      string  true  "Car ID (UUID)"
 // @Success      200  {object}  SuccessResponse{data=domain.Car}
 // @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
 // @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
 // @Router       /api/v1/cars/{id} [get]
 func (h *CarHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
@@ -124,7 +140,7 @@ func (h *CarHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusNotFound, "car not found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "failed to get car")
+		respondServerError(w, r, "failed to get car", err)
 		return
 	}
 
@@ -142,7 +158,9 @@ func (h *CarHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Param        car  body      domain.UpdateCarRequest  true  "Car data to update"
 // @Success      200  {object}  SuccessResponse{data=domain.Car}
 // @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
 // @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
 // @Failure      500  {object}  ErrorResponse
 // @Router       /api/v1/cars/{id} [put]
 func (h *CarHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -164,7 +182,7 @@ func (h *CarHandler) Update(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusNotFound, "car not found")
 			return
 		}
-		respondError(w, http.StatusInternalServerError, "failed to update car")
+		respondServerError(w, r, "failed to update car", err)
 		return
 	}
 
@@ -180,6 +198,8 @@ func (h *CarHandler) Update(w http.ResponseWriter, r *http.Request) {
 // @Param        id   path      string  true  "Car ID (UUID)"
 // @Success      204  "No Content"
 // @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
 // @Failure      500  {object}  ErrorResponse
 // @Router       /api/v1/cars/{id} [delete]
 func (h *CarHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -190,9 +210,89 @@ func (h *CarHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.usecase.Delete(r.Context(), id); err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to delete car")
+		respondServerError(w, r, "failed to delete car", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// Restore godoc
+// @Summary      Restore a car
+// @Description  Undo the soft delete of a car by ID
+// @Tags         cars
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Car ID (UUID)"
+// @Success      200  {object}  SuccessResponse{data=domain.Car}
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /api/v1/cars/{id}/restore [post]
+func (h *CarHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid car id")
+		return
+	}
+
+	car, err := h.usecase.Restore(r.Context(), id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(w, http.StatusNotFound, "deleted car not found")
+			return
+		}
+		respondServerError(w, r, "failed to restore car", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: car})
+}
+
+// History godoc
+// @Summary      Car change history
+// @Description  Get the audit trail of a car, newest first: who created, updated, deleted or restored it, when, and which fields changed. Available for deleted cars too.
+// @Tags         cars
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      string  true   "Car ID (UUID)"
+// @Param        offset  query     int     false  "Offset"  default(0)
+// @Param        limit   query     int     false  "Limit"   default(20)
+// @Success      200     {object}  PaginatedResponse{data=[]domain.CarAudit}
+// @Failure      400     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      429     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /api/v1/cars/{id}/history [get]
+func (h *CarHandler) History(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid car id")
+		return
+	}
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	audits, total, err := h.usecase.History(r.Context(), id, offset, limit)
+	if err != nil {
+		respondServerError(w, r, "failed to get car history", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, PaginatedResponse{
+		Data:   audits,
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+	})
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HealthCheck func() error
+
+type HealthHandler struct {
+	checks map[string]HealthCheck
+}
+
+type HealthResponse struct {
+	Status string            `json:"status" example:"ok"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+func NewHealthHandler(checks map[string]HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+func (h *HealthHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/health", h.Health)
+}
+
+// Health godoc
+// @Summary      Health check
+// @Description  Reports "ok", or "degraded" with the failing checks when a background component (e.g. the log consumer) is unhealthy
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  HealthResponse
+// @Router       /health [get]
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	resp := HealthResponse{Status: "ok"}
+
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := h.checks[name](); err != nil {
+			if resp.Checks == nil {
+				resp.Checks = map[string]string{}
+			}
+			resp.Checks[name] = err.Error()
+			resp.Status = "degraded"
+		}
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
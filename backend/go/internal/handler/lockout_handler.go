@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/middleware"
+	"github.com/gino/cars-crud/internal/usecase"
+)
+
+type LockoutHandler struct {
+	usecase *usecase.LockoutUsecase
+}
+
+func NewLockoutHandler(uc *usecase.LockoutUsecase) *LockoutHandler {
+	return &LockoutHandler{usecase: uc}
+}
+
+func (h *LockoutHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin/lockouts", func(r chi.Router) {
+		r.Use(middleware.RequireScope(domain.ScopeAdmin))
+		r.Get("/", h.GetAll)
+		r.Delete("/{subject}", h.Unlock)
+	})
+}
+
+// GetAll godoc
+// @Summary      List lockouts
+// @Description  Lists the IPs and API key prefixes currently locked out of /auth/validate after repeated failures, soonest to expire first
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  SuccessResponse{data=[]domain.Lockout}
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/lockouts [get]
+func (h *LockoutHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := h.usecase.List(r.Context())
+	if err != nil {
+		respondServerError(w, r, "failed to list lockouts", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: lockouts})
+}
+
+// Unlock godoc
+// @Summary      Lift a lockout
+// @Description  Ends the lockout of a subject ("ip:<address>" or "key:<key prefix>") and forgets its failures, so its next lockout starts at the base duration
+// @Tags         admin
+// @Security     BearerAuth
+// @Param        subject  path  string  true  "Lockout subject"
+// @Success      204  "No Content"
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/lockouts/{subject} [delete]
+func (h *LockoutHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	if err := h.usecase.Unlock(r.Context(), chi.URLParam(r, "subject")); err != nil {
+		respondServerError(w, r, "failed to lift lockout", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
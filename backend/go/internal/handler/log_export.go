@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gino/cars-crud/internal/domain"
+)
+
+// logEncoder writes exported logs one entry at a time.
+type logEncoder interface {
+	ContentType() string
+	Extension() string
+	Begin() error
+	Encode(domain.RequestLog) error
+	Flush() error
+}
+
+func newLogEncoder(format string, w io.Writer) (logEncoder, bool) {
+	switch format {
+	case "", "ndjson":
+		return &ndjsonLogEncoder{enc: json.NewEncoder(w)}, true
+	case "csv":
+		return &csvLogEncoder{w: csv.NewWriter(w)}, true
+	default:
+		return nil, false
+	}
+}
+
+type ndjsonLogEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonLogEncoder) ContentType() string { return "application/x-ndjson" }
+func (e *ndjsonLogEncoder) Extension() string   { return "ndjson" }
+func (e *ndjsonLogEncoder) Begin() error        { return nil }
+func (e *ndjsonLogEncoder) Flush() error        { return nil }
+
+func (e *ndjsonLogEncoder) Encode(entry domain.RequestLog) error {
+	return e.enc.Encode(entry)
+}
+
+// csvLogEncoder writes the request metadata only; headers and bodies do not
+// fit a flat row and are available in the NDJSON export.
+type csvLogEncoder struct {
+	w *csv.Writer
+}
+
+var csvLogColumns = []string{
+	"timestamp", "method", "path", "route", "query", "status_code", "duration_ms",
+	"response_bytes", "ip", "user_agent", "request_id", "principal",
+}
+
+func (e *csvLogEncoder) ContentType() string { return "text/csv; charset=utf-8" }
+func (e *csvLogEncoder) Extension() string   { return "csv" }
+
+func (e *csvLogEncoder) Begin() error {
+	return e.w.Write(csvLogColumns)
+}
+
+func (e *csvLogEncoder) Encode(entry domain.RequestLog) error {
+	return e.w.Write([]string{
+		entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		csvCell(entry.Method),
+		csvCell(entry.Path),
+		csvCell(entry.Route),
+		csvCell(entry.Query),
+		strconv.Itoa(entry.StatusCode),
+		strconv.FormatInt(entry.Duration, 10),
+		strconv.FormatInt(entry.ResponseBytes, 10),
+		csvCell(entry.IP),
+		csvCell(entry.UserAgent),
+		csvCell(entry.RequestID),
+		csvCell(entry.Principal),
+	})
+}
+
+// csvCell neutralises client-supplied text that a spreadsheet would run as
+// a formula (CSV injection) by prefixing it with a quote.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (e *csvLogEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
@@ -1,38 +1,95 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/middleware"
 	"github.com/gino/cars-crud/internal/repository"
+	"github.com/gino/cars-crud/pkg/logger"
 )
 
+type LogStream interface {
+	Subscribe() (<-chan domain.RequestLog, func())
+}
+
 type LogHandler struct {
-	repo repository.LogRepository
+	repo   repository.LogRepository
+	stream LogStream
 }
 
-func NewLogHandler(repo repository.LogRepository) *LogHandler {
-	return &LogHandler{repo: repo}
+func NewLogHandler(repo repository.LogRepository, stream LogStream) *LogHandler {
+	return &LogHandler{repo: repo, stream: stream}
 }
 
 func (h *LogHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/api/v1/logs", func(r chi.Router) {
+		r.Use(middleware.RequireScope(domain.ScopeLogsRead))
 		r.Get("/", h.GetAll)
+		r.Get("/stats", h.Stats)
 	})
 }
 
+// RegisterStreamRoutes registers long-lived routes that must not be wrapped
+// in the request timeout.
+func (h *LogHandler) RegisterStreamRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireScope(domain.ScopeLogsRead))
+		r.Get("/api/v1/logs/stream", h.Stream)
+		r.Get("/api/v1/logs/export", h.Export)
+	})
+}
+
+const streamHeartbeat = 15 * time.Second
+
+// exportFlushEvery controls how many exported entries are written between
+// flushes, so clients and proxies see steady progress on long exports.
+const exportFlushEvery = 500
+
+var statsIntervals = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+const maxStatsBuckets = 1440
+
 // GetAll godoc
 // @Summary      List request logs
-// @Description  Get a paginated list of all request logs from MongoDB
+// @Description  Get a paginated, filterable list of request logs from MongoDB
 // @Tags         logs
 // @Produce      json
 // @Security     BearerAuth
-// @Param        offset  query     int  false  "Offset"  default(0)
-// @Param        limit   query     int  false  "Limit"   default(20)
+// @Param        offset           query     int     false  "Offset"  default(0)
+// @Param        limit            query     int     false  "Limit"   default(20)
+// @Param        method           query     string  false  "HTTP method"  example(GET)
+// @Param        route            query     string  false  "Route pattern"  example(/api/v1/cars/{id})
+// @Param        path             query     string  false  "Exact path"
+// @Param        path_prefix      query     string  false  "Path prefix"  example(/api/v1/cars)
+// @Param        status           query     string  false  "Status code or class"  example(5xx)
+// @Param        status_min       query     int     false  "Minimum status code"
+// @Param        status_max       query     int     false  "Maximum status code"
+// @Param        min_duration_ms  query     int     false  "Minimum duration in ms"
+// @Param        max_duration_ms  query     int     false  "Maximum duration in ms"
+// @Param        ip               query     string  false  "Client IP"
+// @Param        user_agent       query     string  false  "User agent substring (case-insensitive)"
+// @Param        principal        query     string  false  "Authenticated principal (JWT subject)"
+// @Param        from             query     string  false  "Start of time window (RFC 3339)"
+// @Param        to               query     string  false  "End of time window (RFC 3339)"
 // @Success      200     {object}  PaginatedResponse{data=[]domain.RequestLog}
+// @Failure      400     {object}  ErrorResponse
 // @Failure      401     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      429     {object}  ErrorResponse
 // @Failure      500     {object}  ErrorResponse
 // @Router       /api/v1/logs [get]
 func (h *LogHandler) GetAll(w http.ResponseWriter, r *http.Request) {
@@ -46,9 +103,15 @@ func (h *LogHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		limit = 100
 	}
 
-	logs, total, err := h.repo.GetAll(r.Context(), offset, limit)
+	filter, err := parseLogFilter(r.URL.Query())
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "failed to list logs")
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logs, total, err := h.repo.GetAll(r.Context(), filter, offset, limit)
+	if err != nil {
+		respondServerError(w, r, "failed to list logs", err)
 		return
 	}
 
@@ -59,3 +122,305 @@ func (h *LogHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		Limit:  limit,
 	})
 }
+
+// Stats godoc
+// @Summary      Request log analytics
+// @Description  Per-route request counts, 5xx error rates and p50/p95/p99 latency, bucketed by interval over a time window (default: last 24 hours). Accepts the same filters as the list endpoint.
+// @Tags         logs
+// @Produce      json
+// @Security     BearerAuth
+// @Param        interval     query     string  false  "Bucket size"  Enums(minute, hour, day)  default(hour)
+// @Param        from         query     string  false  "Start of time window (RFC 3339)"
+// @Param        to           query     string  false  "End of time window (RFC 3339)"
+// @Param        method       query     string  false  "HTTP method"
+// @Param        route        query     string  false  "Route pattern"
+// @Param        path_prefix  query     string  false  "Path prefix"
+// @Param        status       query     string  false  "Status code or class"
+// @Success      200  {object}  SuccessResponse{data=[]domain.LogStatsBucket}
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /api/v1/logs/stats [get]
+func (h *LogHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseLogFilter(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "hour"
+	}
+	step, ok := statsIntervals[interval]
+	if !ok {
+		respondError(w, http.StatusBadRequest, "interval must be one of minute, hour, day")
+		return
+	}
+
+	to := time.Now()
+	if filter.To != nil {
+		to = *filter.To
+	}
+	from := to.Add(-24 * time.Hour)
+	if filter.From != nil {
+		from = *filter.From
+	}
+	if !from.Before(to) {
+		respondError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+	if to.Sub(from)/step > maxStatsBuckets {
+		respondError(w, http.StatusBadRequest, "time window too large for interval")
+		return
+	}
+	filter.From, filter.To = &from, &to
+
+	stats, err := h.repo.Stats(r.Context(), domain.LogStatsQuery{Filter: filter, Interval: interval})
+	if err != nil {
+		respondServerError(w, r, "failed to compute log stats", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: stats})
+}
+
+// Stream godoc
+// @Summary      Live tail of request logs
+// @Description  Streams request logs as Server-Sent Events (event "request_log") as they reach Kafka from any API instance, with a heartbeat comment every 15 seconds. Accepts the same filters as the list endpoint.
+// @Tags         logs
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        method       query     string  false  "HTTP method"
+// @Param        route        query     string  false  "Route pattern"
+// @Param        path         query     string  false  "Exact path"
+// @Param        path_prefix  query     string  false  "Path prefix"
+// @Param        status       query     string  false  "Status code or class"
+// @Success      200  {string}  string  "text/event-stream"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Router       /api/v1/logs/stream [get]
+func (h *LogHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseLogFilter(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	logs, unsubscribe := h.stream.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case entry := <-logs:
+			if !filter.Matches(entry) {
+				continue
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: request_log\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// Export godoc
+// @Summary      Export request logs
+// @Description  Streams every matching request log in timestamp order as NDJSON (default) or CSV, reading from a database cursor so large time ranges are never held in memory. CSV rows omit headers and bodies. Accepts the same filters as the list endpoint.
+// @Tags         logs
+// @Produce      application/x-ndjson
+// @Produce      text/csv
+// @Security     BearerAuth
+// @Param        format       query     string  false  "Export format"  Enums(ndjson, csv)  default(ndjson)
+// @Param        from         query     string  false  "Start of time window (RFC 3339)"
+// @Param        to           query     string  false  "End of time window (RFC 3339)"
+// @Param        method       query     string  false  "HTTP method"
+// @Param        route        query     string  false  "Route pattern"
+// @Param        path         query     string  false  "Exact path"
+// @Param        path_prefix  query     string  false  "Path prefix"
+// @Param        status       query     string  false  "Status code or class"
+// @Success      200  {string}  string  "NDJSON or CSV file"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /api/v1/logs/export [get]
+func (h *LogHandler) Export(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseLogFilter(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	enc, ok := newLogEncoder(r.URL.Query().Get("format"), w)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "format must be one of ndjson, csv")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	started := false
+	begin := func() error {
+		started = true
+		w.Header().Set("Content-Type", enc.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(
+			`attachment; filename="request_logs-%s.%s"`, time.Now().UTC().Format("20060102T150405Z"), enc.Extension(),
+		))
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		return enc.Begin()
+	}
+
+	count := 0
+	err = h.repo.ForEach(r.Context(), filter, func(entry domain.RequestLog) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+		if count++; count%exportFlushEvery == 0 {
+			if err := enc.Flush(); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// Once the body has started the status is already sent, so the
+		// truncated export is the only signal left to the client.
+		if !started {
+			respondServerError(w, r, "failed to export logs", err)
+		} else if r.Context().Err() == nil {
+			logger.FromContext(r.Context()).Error("log export aborted", slog.Int("written", count), logger.Err(err))
+		}
+		return
+	}
+
+	if !started {
+		if err := begin(); err != nil {
+			return
+		}
+	}
+	enc.Flush()
+}
+
+func parseLogFilter(q url.Values) (domain.LogFilter, error) {
+	filter := domain.LogFilter{
+		Method:     strings.ToUpper(q.Get("method")),
+		Route:      q.Get("route"),
+		Path:       q.Get("path"),
+		PathPrefix: q.Get("path_prefix"),
+		IP:         q.Get("ip"),
+		UserAgent:  q.Get("user_agent"),
+		Principal:  q.Get("principal"),
+	}
+
+	var err error
+	if filter.StatusMin, err = intParam(q, "status_min"); err != nil {
+		return filter, err
+	}
+	if filter.StatusMax, err = intParam(q, "status_max"); err != nil {
+		return filter, err
+	}
+	if filter.MinDurationMs, err = int64Param(q, "min_duration_ms"); err != nil {
+		return filter, err
+	}
+	if filter.MaxDurationMs, err = int64Param(q, "max_duration_ms"); err != nil {
+		return filter, err
+	}
+	if filter.From, err = timeParam(q, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = timeParam(q, "to"); err != nil {
+		return filter, err
+	}
+
+	if status := q.Get("status"); status != "" {
+		if class, ok := strings.CutSuffix(strings.ToLower(status), "xx"); ok {
+			n, err := strconv.Atoi(class)
+			if err != nil || n < 1 || n > 5 {
+				return filter, fmt.Errorf("invalid status %q", status)
+			}
+			filter.StatusMin, filter.StatusMax = n*100, n*100+99
+		} else {
+			n, err := strconv.Atoi(status)
+			if err != nil {
+				return filter, fmt.Errorf("invalid status %q", status)
+			}
+			filter.StatusCode = n
+		}
+	}
+
+	return filter, nil
+}
+
+func intParam(q url.Values, name string) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return n, nil
+}
+
+func int64Param(q url.Values, name string) (*int64, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return &n, nil
+}
+
+func timeParam(q url.Values, name string) (*time.Time, error) {
+	v := q.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q, expected RFC 3339", name, v)
+	}
+	return &t, nil
+}
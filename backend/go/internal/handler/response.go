@@ -3,6 +3,8 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/gino/cars-crud/pkg/logger"
 )
 
 type SuccessResponse struct {
@@ -29,3 +31,10 @@ func respondJSON(w http.ResponseWriter, status int, payload interface{}) {
 func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, ErrorResponse{Error: message})
 }
+
+// respondServerError logs err with the request logger and responds with a
+// generic 500 so internal details are not leaked to the client.
+func respondServerError(w http.ResponseWriter, r *http.Request, message string, err error) {
+	logger.FromContext(r.Context()).Error(message, logger.Err(err))
+	respondError(w, http.StatusInternalServerError, message)
+}
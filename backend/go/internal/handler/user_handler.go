@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/middleware"
+	"github.com/gino/cars-crud/internal/usecase"
+)
+
+const (
+	minPasswordLength = 12
+	// bcrypt ignores everything past 72 bytes.
+	maxPasswordBytes = 72
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._@-]{2,63}$`)
+
+type UserHandler struct {
+	usecase *usecase.UserUsecase
+}
+
+func NewUserHandler(uc *usecase.UserUsecase) *UserHandler {
+	return &UserHandler{usecase: uc}
+}
+
+func (h *UserHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin/users", func(r chi.Router) {
+		r.Use(middleware.RequireScope(domain.ScopeAdmin))
+		r.Post("/", h.Create)
+		r.Get("/", h.GetAll)
+		r.Get("/{id}", h.GetByID)
+		r.Put("/{id}", h.Update)
+		r.Delete("/{id}", h.Delete)
+	})
+}
+
+// Create godoc
+// @Summary      Create a user
+// @Description  Creates a user who signs in at /auth/login. Roles map to scopes: viewer (cars:read), editor (cars:read, cars:write), auditor (cars:read, logs:read) and admin (every scope). Passwords need at least 12 characters and at most 72 bytes.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        body  body      domain.CreateUserRequest  true  "User details"
+// @Success      201   {object}  SuccessResponse{data=domain.User}
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      409   {object}  ErrorResponse
+// @Failure      429   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /admin/users [post]
+func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	req.Username = domain.NormalizeUsername(req.Username)
+	if !usernamePattern.MatchString(req.Username) {
+		respondError(w, http.StatusBadRequest, "username must be 3-64 characters of a-z, 0-9, '.', '_', '@' or '-'")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if msg := validatePassword(req.Password); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	roles, msg := normalizeRoles(req.Roles)
+	if msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	req.Roles = roles
+
+	user, err := h.usecase.Create(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, domain.ErrUsernameTaken) {
+			respondError(w, http.StatusConflict, "username already taken")
+			return
+		}
+		respondServerError(w, r, "failed to create user", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, SuccessResponse{Data: user})
+}
+
+// GetAll godoc
+// @Summary      List users
+// @Description  Get a paginated list of users, ordered by username
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        offset  query     int  false  "Offset"  default(0)
+// @Param        limit   query     int  false  "Limit"   default(20)
+// @Success      200     {object}  PaginatedResponse{data=[]domain.User}
+// @Failure      401     {object}  ErrorResponse
+// @Failure      403     {object}  ErrorResponse
+// @Failure      429     {object}  ErrorResponse
+// @Failure      500     {object}  ErrorResponse
+// @Router       /admin/users [get]
+func (h *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	users, total, err := h.usecase.GetAll(r.Context(), offset, limit)
+	if err != nil {
+		respondServerError(w, r, "failed to list users", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, PaginatedResponse{
+		Data:   users,
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+	})
+}
+
+// GetByID godoc
+// @Summary      Get a user
+// @Description  Get a single user by ID
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID (UUID)"
+// @Success      200  {object}  SuccessResponse{data=domain.User}
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/users/{id} [get]
+func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	user, err := h.usecase.GetByID(r.Context(), id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		respondServerError(w, r, "failed to get user", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: user})
+}
+
+// Update godoc
+// @Summary      Update a user
+// @Description  Changes a user's name, roles, disabled flag or password; fields left out are kept. Disabled users cannot sign in, and role changes and disabling apply to existing sessions at their next refresh.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string                    true  "User ID (UUID)"
+// @Param        body  body      domain.UpdateUserRequest  true  "Fields to change"
+// @Success      200   {object}  SuccessResponse{data=domain.User}
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      404   {object}  ErrorResponse
+// @Failure      429   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /admin/users/{id} [put]
+func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	var req domain.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+	if req.Password != nil {
+		if msg := validatePassword(*req.Password); msg != "" {
+			respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+	if req.Roles != nil {
+		roles, msg := normalizeRoles(*req.Roles)
+		if msg != "" {
+			respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+		req.Roles = &roles
+	}
+
+	user, err := h.usecase.Update(r.Context(), id, req)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		respondServerError(w, r, "failed to update user", err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, SuccessResponse{Data: user})
+}
+
+// Delete godoc
+// @Summary      Delete a user
+// @Description  Deletes a user for good. Their existing sessions stop refreshing; to keep the account, disable it instead.
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID (UUID)"
+// @Success      204  "No Content"
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /admin/users/{id} [delete]
+func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	if err := h.usecase.Delete(r.Context(), id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		respondServerError(w, r, "failed to delete user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validatePassword returns a message describing why password is not
+// acceptable, or "" when it is.
+func validatePassword(password string) string {
+	switch {
+	case len([]rune(password)) < minPasswordLength:
+		return fmt.Sprintf("password must be at least %d characters", minPasswordLength)
+	case len(password) > maxPasswordBytes:
+		return fmt.Sprintf("password must be at most %d bytes", maxPasswordBytes)
+	}
+	return ""
+}
+
+// normalizeRoles checks, sorts and dedupes roles, returning a message when
+// they are not acceptable.
+func normalizeRoles(roles []string) ([]string, string) {
+	if len(roles) == 0 {
+		return nil, "roles is required"
+	}
+	for _, role := range roles {
+		if !domain.ValidRole(role) {
+			return nil, fmt.Sprintf("unknown role %q", role)
+		}
+	}
+	return slices.Compact(slices.Sorted(slices.Values(roles))), ""
+}
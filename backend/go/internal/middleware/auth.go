@@ -2,22 +2,49 @@ package middleware
 
 import (
 	"context"
+	"crypto/x509"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/pkg/logger"
 )
 
 type contextKey string
 
-const ClaimsKey contextKey = "claims"
+// TokenVerifier checks bearer tokens and returns their principal. It fails with domain.ErrInvalidToken
+// or domain.ErrTokenRevoked for rejected tokens; any other error means the
+// token could not be checked.
+type TokenVerifier interface {
+	Verify(ctx context.Context, raw string) (*domain.Principal, error)
+}
+
+// CertMapper maps a client certificate verified during the TLS handshake
+// onto a principal, reporting false for certificates it does not accept.
+type CertMapper interface {
+	Principal(cert *x509.Certificate) (*domain.Principal, bool)
+}
 
-func JWTAuth(secret string) func(http.Handler) http.Handler {
+// JWTAuth authenticates requests by bearer token. When certs is not nil, a
+// request without an Authorization header may authenticate with a verified
+// client certificate instead.
+func JWTAuth(tokens TokenVerifier, certs CertMapper) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			header := r.Header.Get("Authorization")
 			if header == "" {
-				http.Error(w, `{"error":"missing authorization header"}`, http.StatusUnauthorized)
+				if certs == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+					http.Error(w, `{"error":"missing authorization header"}`, http.StatusUnauthorized)
+					return
+				}
+				principal, ok := certs.Principal(r.TLS.VerifiedChains[0][0])
+				if !ok {
+					http.Error(w, `{"error":"client certificate not authorized"}`, http.StatusUnauthorized)
+					return
+				}
+				serveAuthenticated(w, r, next, principal)
 				return
 			}
 
@@ -27,19 +54,29 @@ func JWTAuth(secret string) func(http.Handler) http.Handler {
 				return
 			}
 
-			token, err := jwt.Parse(parts[1], func(t *jwt.Token) (interface{}, error) {
-				if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, jwt.ErrSignatureInvalid
-				}
-				return []byte(secret), nil
-			})
-			if err != nil || !token.Valid {
+			principal, err := tokens.Verify(r.Context(), parts[1])
+			switch {
+			case errors.Is(err, domain.ErrTokenRevoked):
+				http.Error(w, `{"error":"token has been revoked"}`, http.StatusUnauthorized)
+				return
+			case errors.Is(err, domain.ErrInvalidToken):
 				http.Error(w, `{"error":"invalid or expired token"}`, http.StatusUnauthorized)
 				return
+			case err != nil:
+				logger.FromContext(r.Context()).Error("failed to verify token", logger.Err(err))
+				http.Error(w, `{"error":"unable to verify token"}`, http.StatusServiceUnavailable)
+				return
 			}
 
-			ctx := context.WithValue(r.Context(), ClaimsKey, token.Claims)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			serveAuthenticated(w, r, next, principal)
 		})
 	}
 }
+
+func serveAuthenticated(w http.ResponseWriter, r *http.Request, next http.Handler, principal *domain.Principal) {
+	ctx := domain.WithPrincipal(r.Context(), principal)
+	setLogPrincipal(ctx, principal.Subject)
+	ctx = logger.WithContext(ctx, logger.FromContext(ctx).With(slog.String("principal", principal.Subject)))
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
+	"github.com/gino/cars-crud/pkg/logger"
+)
+
+// ContextLogger stores a child of base carrying the request ID and route on
+// the request context, for retrieval with logger.FromContext. It must run
+// after chi's RequestID middleware.
+func ContextLogger(base *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := base.With(slog.String("request_id", chimiddleware.GetReqID(r.Context())))
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				l = slog.New(routeHandler{Handler: l.Handler(), rctx: rctx})
+			}
+			next.ServeHTTP(w, r.WithContext(logger.WithContext(r.Context(), l)))
+		})
+	}
+}
+
+// routeHandler adds the chi route pattern when a record is written, since the
+// pattern is only complete once routing has reached the handler.
+type routeHandler struct {
+	slog.Handler
+	rctx *chi.Context
+}
+
+func (h routeHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if pattern := h.rctx.RoutePattern(); pattern != "" {
+		rec = rec.Clone()
+		rec.AddAttrs(slog.String("route", pattern))
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h routeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return routeHandler{Handler: h.Handler.WithAttrs(attrs), rctx: h.rctx}
+}
+
+func (h routeHandler) WithGroup(name string) slog.Handler {
+	return routeHandler{Handler: h.Handler.WithGroup(name), rctx: h.rctx}
+}
@@ -1,16 +1,74 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+
 	"github.com/gino/cars-crud/internal/domain"
 	"github.com/gino/cars-crud/internal/queue"
 )
 
+const logFieldsKey contextKey = "log_fields"
+
+// logFields collects values that are only known further down the middleware
+// chain, such as the authenticated principal.
+type logFields struct {
+	principal string
+}
+
+func setLogPrincipal(ctx context.Context, principal string) {
+	if f, ok := ctx.Value(logFieldsKey).(*logFields); ok {
+		f.principal = principal
+	}
+}
+
+type LogOptions struct {
+	CaptureBodies bool
+	MaxBodyBytes  int
+	RedactFields  []string
+	RedactHeaders []string
+
+	// ExcludePaths lists exact paths, or prefixes when ending in "/" or "*".
+	ExcludePaths     []string
+	SampleRate       float64
+	RouteSampleRates map[string]float64
+	AlwaysLogErrors  bool
+	SlowThreshold    time.Duration
+}
+
+// bodyCapture keeps the first limit bytes written to it and remembers whether
+// anything beyond that was dropped.
+type bodyCapture struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *bodyCapture) Write(p []byte) (int, error) {
+	if room := c.limit - c.buf.Len(); room < len(p) {
+		c.truncated = true
+		p = p[:max(room, 0)]
+	}
+	c.buf.Write(p)
+	return len(p), nil
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
 type statusRecorder struct {
 	http.ResponseWriter
 	statusCode int
+	bytes      int64
+	body       *bodyCapture
 }
 
 func (r *statusRecorder) WriteHeader(code int) {
@@ -18,22 +76,79 @@ func (r *statusRecorder) WriteHeader(code int) {
 	r.ResponseWriter.WriteHeader(code)
 }
 
-func RequestLogger(producer *queue.LogProducer) func(http.Handler) http.Handler {
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	if r.body != nil {
+		r.body.Write(b[:n])
+	}
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streaming responses.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func RequestLogger(producer *queue.LogProducer, opts LogOptions) func(http.Handler) http.Handler {
+	redact := newRedactor(opts.RedactFields, opts.RedactHeaders)
+	sampler := newLogSampler(opts)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !sampler.alwaysRules() && sampler.excluded(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			start := time.Now()
 			rec := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			fields := &logFields{}
 
-			next.ServeHTTP(rec, r)
+			var reqBody *bodyCapture
+			if opts.CaptureBodies {
+				reqBody = &bodyCapture{limit: opts.MaxBodyBytes}
+				rec.body = &bodyCapture{limit: opts.MaxBodyBytes}
+				if r.Body != nil && r.Body != http.NoBody {
+					r.Body = teeReadCloser{Reader: io.TeeReader(r.Body, reqBody), Closer: r.Body}
+				}
+			}
+
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), logFieldsKey, fields)))
+
+			duration := time.Since(start)
+
+			var route string
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				route = rctx.RoutePattern()
+			}
+
+			if !sampler.keep(r.URL.Path, route, rec.statusCode, duration) {
+				return
+			}
 
 			logEntry := domain.RequestLog{
-				Method:     r.Method,
-				Path:       r.URL.Path,
-				StatusCode: rec.statusCode,
-				Duration:   time.Since(start).Milliseconds(),
-				IP:         r.RemoteAddr,
-				UserAgent:  r.UserAgent(),
-				Timestamp:  start,
+				Method:        r.Method,
+				Path:          r.URL.Path,
+				Route:         route,
+				Query:         redact.query(r.URL.RawQuery),
+				StatusCode:    rec.statusCode,
+				Duration:      duration.Milliseconds(),
+				ResponseBytes: rec.bytes,
+				IP:            ClientIP(r),
+				UserAgent:     r.UserAgent(),
+				RequestID:     chimiddleware.GetReqID(r.Context()),
+				Principal:     fields.principal,
+				Timestamp:     start,
+			}
+
+			if opts.CaptureBodies {
+				logEntry.RequestHeaders = redact.header(r.Header)
+				logEntry.RequestBody = redact.body(reqBody.buf.Bytes())
+				logEntry.RequestBodyTruncated = reqBody.truncated
+				logEntry.ResponseBody = redact.body(rec.body.buf.Bytes())
+				logEntry.ResponseBodyTruncated = rec.body.truncated
 			}
 
 			_ = producer.Publish(r.Context(), logEntry)
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+	"github.com/gino/cars-crud/pkg/logger"
+)
+
+// RateLimiter limits requests per API key, or per client IP before
+// authentication. Counts live in store; while store fails they are kept in
+// fallback so limits keep applying, per instance.
+type RateLimiter struct {
+	store     repository.RateLimiter
+	fallback  repository.RateLimiter
+	keyLimits map[string]domain.RateLimit
+	degraded  atomic.Bool
+}
+
+// NewRateLimiter creates a limiter. keyLimits overrides the group limits
+// for individual API keys, by principal subject.
+func NewRateLimiter(store, fallback repository.RateLimiter, keyLimits map[string]domain.RateLimit) *RateLimiter {
+	return &RateLimiter{store: store, fallback: fallback, keyLimits: keyLimits}
+}
+
+// Limit returns middleware that counts the requests of one route group
+// against limit and answers 429 once a client is over it. Groups are
+// counted separately. It must run after JWTAuth on protected groups.
+func (l *RateLimiter) Limit(group string, limit domain.RateLimit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client, limit := l.client(r, limit)
+			result := l.allow(r.Context(), group+":"+client, limit)
+
+			h := w.Header()
+			h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, seconds(limit.Window)))
+			h.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+			if !result.Allowed {
+				h.Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+				http.Error(w, `{"error":"rate limit exceeded"}`, http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// client identifies who a request is counted for and the limit that
+// applies to them.
+func (l *RateLimiter) client(r *http.Request, limit domain.RateLimit) (string, domain.RateLimit) {
+	if p, ok := domain.PrincipalFromContext(r.Context()); ok {
+		if keyLimit, ok := l.keyLimits[p.Subject]; ok {
+			limit = keyLimit
+		}
+		return "key:" + p.Subject, limit
+	}
+
+	return "ip:" + ClientIP(r), limit
+}
+
+// ClientIP returns the address of the client without its port. Behind
+// RealIP this is the address reported by a trusted proxy.
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func (l *RateLimiter) allow(ctx context.Context, key string, limit domain.RateLimit) domain.RateLimitResult {
+	result, err := l.store.Allow(ctx, key, limit)
+	if err == nil {
+		if l.degraded.Swap(false) {
+			logger.FromContext(ctx).Info("rate limit store recovered")
+		}
+		return result
+	}
+
+	if !l.degraded.Swap(true) {
+		logger.FromContext(ctx).Warn("rate limit store failed, counting in memory", logger.Err(err))
+	}
+	result, _ = l.fallback.Allow(ctx, key, limit)
+	return result
+}
+
+// seconds rounds d up to whole seconds, as the rate limit headers expect.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// RealIP replaces r.RemoteAddr with the client address reported by a proxy,
+// but only when the request comes from one of trusted. X-Forwarded-For is
+// read from the right, skipping trusted proxies, so entries a client put in
+// front of the header are never used; X-Real-IP is the fallback. Requests
+// from other peers keep their socket address, so clients cannot pick the IP
+// that rate limits and lockouts count them under.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		return slices.ContainsFunc(trusted, func(p netip.Prefix) bool { return p.Contains(addr.Unmap()) })
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if peer, err := netip.ParseAddr(ClientIP(r)); err == nil && isTrusted(peer) {
+				if ip, ok := forwardedFor(r.Header.Values("X-Forwarded-For"), isTrusted); ok {
+					r.RemoteAddr = ip.String()
+				} else if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+					r.RemoteAddr = ip.Unmap().String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedFor returns the rightmost untrusted address of the
+// X-Forwarded-For chain, or its leftmost one when every hop is trusted.
+func forwardedFor(headers []string, isTrusted func(netip.Addr) bool) (netip.Addr, bool) {
+	var hops []string
+	for _, h := range headers {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = ip.Unmap()
+		if !isTrusted(client) {
+			break
+		}
+	}
+	return client, client.IsValid()
+}
+
+// ParseTrustedProxies parses IP addresses and CIDR ranges.
+func ParseTrustedProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, v := range values {
+		if !strings.Contains(v, "/") {
+			ip, err := netip.ParseAddr(v)
+			if err != nil {
+				return nil, err
+			}
+			ip = ip.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(v)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// redactor masks configured JSON fields, query parameters and headers before
+// request data leaves the process. Names are matched case-insensitively.
+type redactor struct {
+	fields  map[string]struct{}
+	headers map[string]struct{}
+	pattern *regexp.Regexp
+}
+
+func newRedactor(fields, headers []string) *redactor {
+	r := &redactor{
+		fields:  make(map[string]struct{}, len(fields)),
+		headers: make(map[string]struct{}, len(headers)),
+	}
+
+	quoted := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			r.fields[strings.ToLower(f)] = struct{}{}
+			quoted = append(quoted, regexp.QuoteMeta(f))
+		}
+	}
+	for _, h := range headers {
+		if h = strings.TrimSpace(h); h != "" {
+			r.headers[strings.ToLower(h)] = struct{}{}
+		}
+	}
+
+	if len(quoted) > 0 {
+		// Fallback for bodies that do not parse, e.g. because they were
+		// truncated: a string value (possibly unterminated) or a bare scalar.
+		r.pattern = regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`)
+	}
+	return r
+}
+
+func (r *redactor) body(body []byte) string {
+	if len(r.fields) == 0 || len(body) == 0 {
+		return string(body)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err == nil {
+		if out, err := json.Marshal(r.walk(doc)); err == nil {
+			return string(out)
+		}
+	}
+
+	return r.pattern.ReplaceAllString(string(body), `${1}"`+redacted+`"`)
+}
+
+func (r *redactor) walk(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if _, ok := r.fields[strings.ToLower(k)]; ok {
+				val[k] = redacted
+				continue
+			}
+			val[k] = r.walk(child)
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = r.walk(child)
+		}
+	}
+	return v
+}
+
+func (r *redactor) query(raw string) string {
+	if len(r.fields) == 0 || raw == "" {
+		return raw
+	}
+
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return raw
+	}
+
+	changed := false
+	for k := range values {
+		if _, ok := r.fields[strings.ToLower(k)]; ok {
+			values[k] = []string{redacted}
+			changed = true
+		}
+	}
+	if !changed {
+		return raw
+	}
+	return values.Encode()
+}
+
+func (r *redactor) header(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if _, ok := r.headers[strings.ToLower(k)]; ok {
+			out[k] = redacted
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// logSampler decides which requests are published. Errors and slow requests
+// are always kept when configured; otherwise excluded paths are dropped and
+// the rest are sampled by route pattern, falling back to the default rate.
+type logSampler struct {
+	exact         map[string]struct{}
+	prefixes      []string
+	defaultRate   float64
+	routeRates    map[string]float64
+	alwaysErrors  bool
+	slowThreshold time.Duration
+}
+
+func newLogSampler(opts LogOptions) *logSampler {
+	s := &logSampler{
+		exact:         make(map[string]struct{}),
+		defaultRate:   opts.SampleRate,
+		routeRates:    opts.RouteSampleRates,
+		alwaysErrors:  opts.AlwaysLogErrors,
+		slowThreshold: opts.SlowThreshold,
+	}
+
+	for _, p := range opts.ExcludePaths {
+		switch {
+		case strings.HasSuffix(p, "*"):
+			s.prefixes = append(s.prefixes, strings.TrimSuffix(p, "*"))
+		case strings.HasSuffix(p, "/"):
+			s.prefixes = append(s.prefixes, p)
+		default:
+			s.exact[p] = struct{}{}
+		}
+	}
+	return s
+}
+
+func (s *logSampler) excluded(path string) bool {
+	if _, ok := s.exact[path]; ok {
+		return true
+	}
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// alwaysRules reports whether any rule can force an excluded request to be
+// logged, in which case the request still has to be observed.
+func (s *logSampler) alwaysRules() bool {
+	return s.alwaysErrors || s.slowThreshold > 0
+}
+
+func (s *logSampler) keep(path, route string, status int, duration time.Duration) bool {
+	if s.alwaysErrors && status >= http.StatusInternalServerError {
+		return true
+	}
+	if s.slowThreshold > 0 && duration >= s.slowThreshold {
+		return true
+	}
+	if s.excluded(path) {
+		return false
+	}
+
+	rate := s.defaultRate
+	if r, ok := s.routeRates[route]; ok {
+		rate = r
+	}
+	return rate >= 1 || rand.Float64() < rate
+}
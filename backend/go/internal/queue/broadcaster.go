@@ -0,0 +1,49 @@
+package queue
+
+import (
+	"sync"
+
+	"github.com/gino/cars-crud/internal/domain"
+)
+
+const subscriberBuffer = 64
+
+// broadcaster fans request logs out to live subscribers. Slow subscribers
+// miss entries instead of holding up the tail.
+type broadcaster struct {
+	mu   sync.RWMutex
+	subs map[chan domain.RequestLog]struct{}
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{subs: make(map[chan domain.RequestLog]struct{})}
+}
+
+func (b *broadcaster) subscribe() (<-chan domain.RequestLog, func()) {
+	ch := make(chan domain.RequestLog, subscriberBuffer)
+
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+		})
+	}
+}
+
+func (b *broadcaster) publish(log domain.RequestLog) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subs {
+		select {
+		case ch <- log:
+		default:
+		}
+	}
+}
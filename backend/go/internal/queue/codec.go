@@ -0,0 +1,124 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/queue/pb"
+)
+
+const (
+	jsonContentType     = "application/json"
+	protobufContentType = "application/protobuf"
+
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+)
+
+type requestLogCodec interface {
+	ContentType() string
+	Marshal(log domain.RequestLog) ([]byte, error)
+	Unmarshal(data []byte, log *domain.RequestLog) error
+}
+
+var codecs = map[string]requestLogCodec{
+	jsonContentType:     jsonCodec{},
+	protobufContentType: protobufCodec{},
+}
+
+func codecForEncoding(encoding string) (requestLogCodec, error) {
+	switch encoding {
+	case "", EncodingJSON:
+		return jsonCodec{}, nil
+	case EncodingProtobuf:
+		return protobufCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown message encoding %q", encoding)
+	}
+}
+
+func codecForContentType(contentType string) (requestLogCodec, error) {
+	if contentType == "" {
+		return jsonCodec{}, nil
+	}
+
+	codec, ok := codecs[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+	return codec, nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return jsonContentType }
+
+func (jsonCodec) Marshal(log domain.RequestLog) ([]byte, error) {
+	return json.Marshal(log)
+}
+
+func (jsonCodec) Unmarshal(data []byte, log *domain.RequestLog) error {
+	return json.Unmarshal(data, log)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string { return protobufContentType }
+
+func (protobufCodec) Marshal(log domain.RequestLog) ([]byte, error) {
+	return proto.Marshal(&pb.RequestLog{
+		Method:        log.Method,
+		Path:          log.Path,
+		StatusCode:    int32(log.StatusCode),
+		DurationMs:    log.Duration,
+		Ip:            log.IP,
+		UserAgent:     log.UserAgent,
+		Timestamp:     timestamppb.New(log.Timestamp),
+		Route:         log.Route,
+		Query:         log.Query,
+		ResponseBytes: log.ResponseBytes,
+		RequestId:     log.RequestID,
+		Principal:     log.Principal,
+
+		RequestHeaders:        log.RequestHeaders,
+		RequestBody:           log.RequestBody,
+		RequestBodyTruncated:  log.RequestBodyTruncated,
+		ResponseBody:          log.ResponseBody,
+		ResponseBodyTruncated: log.ResponseBodyTruncated,
+	})
+}
+
+func (protobufCodec) Unmarshal(data []byte, log *domain.RequestLog) error {
+	var msg pb.RequestLog
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+
+	*log = domain.RequestLog{
+		Method:        msg.GetMethod(),
+		Path:          msg.GetPath(),
+		StatusCode:    int(msg.GetStatusCode()),
+		Duration:      msg.GetDurationMs(),
+		IP:            msg.GetIp(),
+		UserAgent:     msg.GetUserAgent(),
+		Route:         msg.GetRoute(),
+		Query:         msg.GetQuery(),
+		ResponseBytes: msg.GetResponseBytes(),
+		RequestID:     msg.GetRequestId(),
+		Principal:     msg.GetPrincipal(),
+
+		RequestHeaders:        msg.GetRequestHeaders(),
+		RequestBody:           msg.GetRequestBody(),
+		RequestBodyTruncated:  msg.GetRequestBodyTruncated(),
+		ResponseBody:          msg.GetResponseBody(),
+		ResponseBodyTruncated: msg.GetResponseBodyTruncated(),
+	}
+	if msg.Timestamp != nil {
+		log.Timestamp = msg.GetTimestamp().AsTime()
+	}
+	return nil
+}
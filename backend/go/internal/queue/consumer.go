@@ -3,19 +3,37 @@ package queue
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/gino/cars-crud/internal/domain"
 	"github.com/gino/cars-crud/pkg/config"
+	"github.com/gino/cars-crud/pkg/logger"
 )
 
+type eventHandler func(ctx context.Context, event Event) error
+
+const consumerGroupID = "log-consumer-group"
+
 type LogConsumer struct {
 	reader     *kafka.Reader
 	collection *mongo.Collection
+	security   *mongo.Collection
+	handlers   map[string]eventHandler
+	maxLag     int64
+
+	processed     atomic.Int64
+	errors        atomic.Int64
+	inserts       atomic.Int64
+	insertNanos   atomic.Int64
+	maxInsertNano atomic.Int64
+	lastMessageAt atomic.Int64
 }
 
 func NewLogConsumer(cfg *config.Config, mongoClient *mongo.Client) *LogConsumer {
@@ -24,20 +42,33 @@ func NewLogConsumer(cfg *config.Config, mongoClient *mongo.Client) *LogConsumer
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:  brokers,
 		Topic:    cfg.KafkaTopic,
-		GroupID:  "log-consumer-group",
+		GroupID:  consumerGroupID,
 		MinBytes: 1,
 		MaxBytes: 10e6,
 	})
 
-	collection := mongoClient.Database(cfg.MongoDB).Collection(cfg.MongoCollection)
+	db := mongoClient.Database(cfg.MongoDB)
 
-	return &LogConsumer{
+	c := &LogConsumer{
 		reader:     reader,
-		collection: collection,
+		collection: db.Collection(cfg.MongoCollection),
+		security:   db.Collection(cfg.MongoSecurityCollection),
+		maxLag:     cfg.KafkaConsumerMaxLag,
 	}
+	c.handlers = map[string]eventHandler{
+		RequestLogEventType: c.handleRequestLog,
+		SecurityEventType:   c.handleSecurityEvent,
+	}
+
+	return c
 }
 
+// Start consumes in the background until ctx is done, logging through the
+// logger carried by ctx.
 func (c *LogConsumer) Start(ctx context.Context) {
+	l := logger.FromContext(ctx).With(slog.String("component", "log_consumer"))
+	ctx = logger.WithContext(ctx, l)
+
 	go func() {
 		for {
 			select {
@@ -49,24 +80,125 @@ func (c *LogConsumer) Start(ctx context.Context) {
 					if ctx.Err() != nil {
 						return
 					}
-					log.Printf("kafka consumer read error: %v", err)
+					l.Error("failed to read message", logger.Err(err))
 					continue
 				}
 
-				var reqLog domain.RequestLog
-				if err := json.Unmarshal(msg.Value, &reqLog); err != nil {
-					log.Printf("kafka consumer unmarshal error: %v", err)
+				c.lastMessageAt.Store(time.Now().UnixNano())
+				if err := c.dispatch(ctx, msg); err != nil {
+					c.errors.Add(1)
+					l.Error("failed to handle message",
+						slog.Int("partition", msg.Partition),
+						slog.Int64("offset", msg.Offset),
+						logger.Err(err),
+					)
 					continue
 				}
-
-				if _, err := c.collection.InsertOne(ctx, reqLog); err != nil {
-					log.Printf("mongo insert error: %v", err)
-				}
+				c.processed.Add(1)
 			}
 		}
 	}()
 }
 
+func (c *LogConsumer) dispatch(ctx context.Context, msg kafka.Message) error {
+	event, err := decodeEvent(msg)
+	if err != nil {
+		return err
+	}
+
+	handle, ok := c.handlers[event.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, event.Type)
+	}
+
+	return handle(ctx, event)
+}
+
+func (c *LogConsumer) handleRequestLog(ctx context.Context, event Event) error {
+	codec, err := codecForContentType(event.DataContentType)
+	if err != nil {
+		return err
+	}
+
+	var reqLog domain.RequestLog
+	if err := codec.Unmarshal(event.Data, &reqLog); err != nil {
+		return fmt.Errorf("unmarshal request log: %w", err)
+	}
+
+	start := time.Now()
+	_, err = c.collection.InsertOne(ctx, reqLog)
+	c.observeInsert(time.Since(start))
+	if err != nil {
+		return fmt.Errorf("mongo insert: %w", err)
+	}
+	return nil
+}
+
+func (c *LogConsumer) handleSecurityEvent(ctx context.Context, event Event) error {
+	if event.DataContentType != jsonContentType {
+		return fmt.Errorf("unsupported content type %q", event.DataContentType)
+	}
+
+	var securityEvent domain.SecurityEvent
+	if err := json.Unmarshal(event.Data, &securityEvent); err != nil {
+		return fmt.Errorf("unmarshal security event: %w", err)
+	}
+
+	start := time.Now()
+	_, err := c.security.InsertOne(ctx, securityEvent)
+	c.observeInsert(time.Since(start))
+	if err != nil {
+		return fmt.Errorf("mongo insert: %w", err)
+	}
+	return nil
+}
+
+func (c *LogConsumer) observeInsert(d time.Duration) {
+	c.inserts.Add(1)
+	c.insertNanos.Add(int64(d))
+	for {
+		current := c.maxInsertNano.Load()
+		if int64(d) <= current || c.maxInsertNano.CompareAndSwap(current, int64(d)) {
+			return
+		}
+	}
+}
+
+func (c *LogConsumer) Stats() domain.ConsumerStats {
+	readerStats := c.reader.Stats()
+
+	stats := domain.ConsumerStats{
+		Name:               "log-consumer",
+		Topic:              readerStats.Topic,
+		GroupID:            consumerGroupID,
+		Processed:          c.processed.Load(),
+		Errors:             c.errors.Load(),
+		Lag:                readerStats.Lag,
+		MaxLag:             c.maxLag,
+		InsertLatencyMaxMs: float64(c.maxInsertNano.Load()) / float64(time.Millisecond),
+		Healthy:            c.maxLag <= 0 || readerStats.Lag <= c.maxLag,
+	}
+
+	if inserts := c.inserts.Load(); inserts > 0 {
+		stats.InsertLatencyAvgMs = float64(c.insertNanos.Load()) / float64(inserts) / float64(time.Millisecond)
+	}
+	if last := c.lastMessageAt.Load(); last > 0 {
+		at := time.Unix(0, last)
+		stats.LastMessageAt = &at
+	}
+
+	return stats
+}
+
+// Health reports an error when the reader lag exceeds the configured maximum.
+func (c *LogConsumer) Health() error {
+	stats := c.Stats()
+	if !stats.Healthy {
+		return fmt.Errorf("consumer lag %d exceeds %d", stats.Lag, stats.MaxLag)
+	}
+	return nil
+}
+
 func (c *LogConsumer) Close() error {
 	return c.reader.Close()
 }
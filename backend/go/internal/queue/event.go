@@ -0,0 +1,237 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	CloudEventsSpecVersion = "1.0"
+	EventSource            = "cars-crud-api"
+
+	RequestLogEventType     = "com.cars-crud.request_log"
+	RequestLogSchemaVersion = "1.0"
+
+	SecurityEventType          = "com.cars-crud.security_event"
+	SecurityEventSchemaVersion = "1.0"
+
+	contentTypeHeader     = "content-type"
+	cloudEventsJSONType   = "application/cloudevents+json"
+	cloudEventsPrefix     = "ce_"
+	schemaVersionAttr     = "schemaversion"
+	supportedSpecVerMajor = "1"
+)
+
+var (
+	ErrUnknownEventType     = errors.New("unknown event type")
+	ErrUnsupportedVersion   = errors.New("unsupported event version")
+	ErrMalformedCloudEvent  = errors.New("malformed cloud event")
+	supportedSchemaVersions = map[string]string{
+		RequestLogEventType: RequestLogSchemaVersion,
+		SecurityEventType:   SecurityEventSchemaVersion,
+	}
+)
+
+// Event is a CloudEvents 1.0 envelope. JSON payloads are sent in structured
+// mode; any other content type is sent in binary mode with ce_* headers.
+type Event struct {
+	SpecVersion     string
+	ID              string
+	Source          string
+	Type            string
+	Time            time.Time
+	DataContentType string
+	SchemaVersion   string
+	Data            []byte
+}
+
+type structuredEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	SchemaVersion   string          `json:"schemaversion"`
+	Data            json.RawMessage `json:"data"`
+}
+
+func newEvent(eventType, schemaVersion, contentType string, data []byte) Event {
+	return Event{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              uuid.NewString(),
+		Source:          EventSource,
+		Type:            eventType,
+		Time:            time.Now().UTC(),
+		DataContentType: contentType,
+		SchemaVersion:   schemaVersion,
+		Data:            data,
+	}
+}
+
+func (e Event) toMessage() (kafka.Message, error) {
+	if e.DataContentType != jsonContentType {
+		return e.toBinaryMessage(), nil
+	}
+
+	value, err := json.Marshal(structuredEvent{
+		SpecVersion:     e.SpecVersion,
+		ID:              e.ID,
+		Source:          e.Source,
+		Type:            e.Type,
+		Time:            e.Time,
+		DataContentType: e.DataContentType,
+		SchemaVersion:   e.SchemaVersion,
+		Data:            e.Data,
+	})
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	return kafka.Message{
+		Key:   []byte(e.ID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: contentTypeHeader, Value: []byte(cloudEventsJSONType)},
+		},
+	}, nil
+}
+
+func (e Event) toBinaryMessage() kafka.Message {
+	return kafka.Message{
+		Key:   []byte(e.ID),
+		Value: e.Data,
+		Headers: []kafka.Header{
+			{Key: contentTypeHeader, Value: []byte(e.DataContentType)},
+			{Key: cloudEventsPrefix + "specversion", Value: []byte(e.SpecVersion)},
+			{Key: cloudEventsPrefix + "id", Value: []byte(e.ID)},
+			{Key: cloudEventsPrefix + "source", Value: []byte(e.Source)},
+			{Key: cloudEventsPrefix + "type", Value: []byte(e.Type)},
+			{Key: cloudEventsPrefix + "time", Value: []byte(e.Time.Format(time.RFC3339Nano))},
+			{Key: cloudEventsPrefix + schemaVersionAttr, Value: []byte(e.SchemaVersion)},
+		},
+	}
+}
+
+// decodeEvent reads a CloudEvent from a Kafka message in either structured or
+// binary mode. Messages produced before the envelope was introduced carry a
+// bare JSON RequestLog and are wrapped as a v1 request log event.
+func decodeEvent(msg kafka.Message) (Event, error) {
+	var (
+		e   Event
+		err error
+	)
+
+	switch {
+	case headerValue(msg, contentTypeHeader) == cloudEventsJSONType:
+		e, err = decodeStructured(msg)
+	case headerValue(msg, cloudEventsPrefix+"specversion") != "":
+		e, err = decodeBinary(msg)
+	default:
+		return legacyEvent(msg), nil
+	}
+	if err != nil {
+		return Event{}, err
+	}
+
+	if err := e.validate(); err != nil {
+		return Event{}, err
+	}
+	return e, nil
+}
+
+func decodeStructured(msg kafka.Message) (Event, error) {
+	var se structuredEvent
+	if err := json.Unmarshal(msg.Value, &se); err != nil {
+		return Event{}, fmt.Errorf("%w: %v", ErrMalformedCloudEvent, err)
+	}
+
+	contentType := se.DataContentType
+	if contentType == "" {
+		contentType = jsonContentType
+	}
+
+	return Event{
+		SpecVersion:     se.SpecVersion,
+		ID:              se.ID,
+		Source:          se.Source,
+		Type:            se.Type,
+		Time:            se.Time,
+		DataContentType: contentType,
+		SchemaVersion:   se.SchemaVersion,
+		Data:            se.Data,
+	}, nil
+}
+
+func decodeBinary(msg kafka.Message) (Event, error) {
+	e := Event{
+		SpecVersion:     headerValue(msg, cloudEventsPrefix+"specversion"),
+		ID:              headerValue(msg, cloudEventsPrefix+"id"),
+		Source:          headerValue(msg, cloudEventsPrefix+"source"),
+		Type:            headerValue(msg, cloudEventsPrefix+"type"),
+		DataContentType: headerValue(msg, contentTypeHeader),
+		SchemaVersion:   headerValue(msg, cloudEventsPrefix+schemaVersionAttr),
+		Data:            msg.Value,
+	}
+
+	if raw := headerValue(msg, cloudEventsPrefix+"time"); raw != "" {
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return Event{}, fmt.Errorf("%w: time: %v", ErrMalformedCloudEvent, err)
+		}
+		e.Time = t
+	}
+
+	return e, nil
+}
+
+func legacyEvent(msg kafka.Message) Event {
+	return Event{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              fmt.Sprintf("%s-%d-%d", msg.Topic, msg.Partition, msg.Offset),
+		Source:          EventSource,
+		Type:            RequestLogEventType,
+		Time:            msg.Time,
+		DataContentType: jsonContentType,
+		SchemaVersion:   RequestLogSchemaVersion,
+		Data:            msg.Value,
+	}
+}
+
+func (e Event) validate() error {
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return fmt.Errorf("%w: id, source and type are required", ErrMalformedCloudEvent)
+	}
+	if majorVersion(e.SpecVersion) != supportedSpecVerMajor {
+		return fmt.Errorf("%w: specversion %q", ErrUnsupportedVersion, e.SpecVersion)
+	}
+
+	supported, ok := supportedSchemaVersions[e.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, e.Type)
+	}
+	if majorVersion(e.SchemaVersion) != majorVersion(supported) {
+		return fmt.Errorf("%w: %s %s=%q", ErrUnsupportedVersion, e.Type, schemaVersionAttr, e.SchemaVersion)
+	}
+	return nil
+}
+
+func majorVersion(v string) string {
+	major, _, _ := strings.Cut(v, ".")
+	return major
+}
+
+func headerValue(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if strings.EqualFold(h.Key, key) {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,281 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: request_log.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// RequestLog mirrors domain.RequestLog. Field numbers must never be reused.
+type RequestLog struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Method                string                 `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	Path                  string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	StatusCode            int32                  `protobuf:"varint,3,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	DurationMs            int64                  `protobuf:"varint,4,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Ip                    string                 `protobuf:"bytes,5,opt,name=ip,proto3" json:"ip,omitempty"`
+	UserAgent             string                 `protobuf:"bytes,6,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	Timestamp             *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Route                 string                 `protobuf:"bytes,8,opt,name=route,proto3" json:"route,omitempty"`
+	Query                 string                 `protobuf:"bytes,9,opt,name=query,proto3" json:"query,omitempty"`
+	ResponseBytes         int64                  `protobuf:"varint,10,opt,name=response_bytes,json=responseBytes,proto3" json:"response_bytes,omitempty"`
+	RequestId             string                 `protobuf:"bytes,11,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Principal             string                 `protobuf:"bytes,12,opt,name=principal,proto3" json:"principal,omitempty"`
+	RequestHeaders        map[string]string      `protobuf:"bytes,13,rep,name=request_headers,json=requestHeaders,proto3" json:"request_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	RequestBody           string                 `protobuf:"bytes,14,opt,name=request_body,json=requestBody,proto3" json:"request_body,omitempty"`
+	RequestBodyTruncated  bool                   `protobuf:"varint,15,opt,name=request_body_truncated,json=requestBodyTruncated,proto3" json:"request_body_truncated,omitempty"`
+	ResponseBody          string                 `protobuf:"bytes,16,opt,name=response_body,json=responseBody,proto3" json:"response_body,omitempty"`
+	ResponseBodyTruncated bool                   `protobuf:"varint,17,opt,name=response_body_truncated,json=responseBodyTruncated,proto3" json:"response_body_truncated,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *RequestLog) Reset() {
+	*x = RequestLog{}
+	mi := &file_request_log_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestLog) ProtoMessage() {}
+
+func (x *RequestLog) ProtoReflect() protoreflect.Message {
+	mi := &file_request_log_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestLog.ProtoReflect.Descriptor instead.
+func (*RequestLog) Descriptor() ([]byte, []int) {
+	return file_request_log_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RequestLog) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *RequestLog) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *RequestLog) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *RequestLog) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *RequestLog) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *RequestLog) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *RequestLog) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *RequestLog) GetRoute() string {
+	if x != nil {
+		return x.Route
+	}
+	return ""
+}
+
+func (x *RequestLog) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *RequestLog) GetResponseBytes() int64 {
+	if x != nil {
+		return x.ResponseBytes
+	}
+	return 0
+}
+
+func (x *RequestLog) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *RequestLog) GetPrincipal() string {
+	if x != nil {
+		return x.Principal
+	}
+	return ""
+}
+
+func (x *RequestLog) GetRequestHeaders() map[string]string {
+	if x != nil {
+		return x.RequestHeaders
+	}
+	return nil
+}
+
+func (x *RequestLog) GetRequestBody() string {
+	if x != nil {
+		return x.RequestBody
+	}
+	return ""
+}
+
+func (x *RequestLog) GetRequestBodyTruncated() bool {
+	if x != nil {
+		return x.RequestBodyTruncated
+	}
+	return false
+}
+
+func (x *RequestLog) GetResponseBody() string {
+	if x != nil {
+		return x.ResponseBody
+	}
+	return ""
+}
+
+func (x *RequestLog) GetResponseBodyTruncated() bool {
+	if x != nil {
+		return x.ResponseBodyTruncated
+	}
+	return false
+}
+
+var File_request_log_proto protoreflect.FileDescriptor
+
+const file_request_log_proto_rawDesc = "" +
+	"\n" +
+	"\x11request_log.proto\x12\x16carscrud.requestlog.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xcd\x05\n" +
+	"\n" +
+	"RequestLog\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x1f\n" +
+	"\vstatus_code\x18\x03 \x01(\x05R\n" +
+	"statusCode\x12\x1f\n" +
+	"\vduration_ms\x18\x04 \x01(\x03R\n" +
+	"durationMs\x12\x0e\n" +
+	"\x02ip\x18\x05 \x01(\tR\x02ip\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x06 \x01(\tR\tuserAgent\x128\n" +
+	"\ttimestamp\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x14\n" +
+	"\x05route\x18\b \x01(\tR\x05route\x12\x14\n" +
+	"\x05query\x18\t \x01(\tR\x05query\x12%\n" +
+	"\x0eresponse_bytes\x18\n" +
+	" \x01(\x03R\rresponseBytes\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\v \x01(\tR\trequestId\x12\x1c\n" +
+	"\tprincipal\x18\f \x01(\tR\tprincipal\x12_\n" +
+	"\x0frequest_headers\x18\r \x03(\v26.carscrud.requestlog.v1.RequestLog.RequestHeadersEntryR\x0erequestHeaders\x12!\n" +
+	"\frequest_body\x18\x0e \x01(\tR\vrequestBody\x124\n" +
+	"\x16request_body_truncated\x18\x0f \x01(\bR\x14requestBodyTruncated\x12#\n" +
+	"\rresponse_body\x18\x10 \x01(\tR\fresponseBody\x126\n" +
+	"\x17response_body_truncated\x18\x11 \x01(\bR\x15responseBodyTruncated\x1aA\n" +
+	"\x13RequestHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B0Z.github.com/gino/cars-crud/internal/queue/pb;pbb\x06proto3"
+
+var (
+	file_request_log_proto_rawDescOnce sync.Once
+	file_request_log_proto_rawDescData []byte
+)
+
+func file_request_log_proto_rawDescGZIP() []byte {
+	file_request_log_proto_rawDescOnce.Do(func() {
+		file_request_log_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_request_log_proto_rawDesc), len(file_request_log_proto_rawDesc)))
+	})
+	return file_request_log_proto_rawDescData
+}
+
+var file_request_log_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_request_log_proto_goTypes = []any{
+	(*RequestLog)(nil),            // 0: carscrud.requestlog.v1.RequestLog
+	nil,                           // 1: carscrud.requestlog.v1.RequestLog.RequestHeadersEntry
+	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+}
+var file_request_log_proto_depIdxs = []int32{
+	2, // 0: carscrud.requestlog.v1.RequestLog.timestamp:type_name -> google.protobuf.Timestamp
+	1, // 1: carscrud.requestlog.v1.RequestLog.request_headers:type_name -> carscrud.requestlog.v1.RequestLog.RequestHeadersEntry
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_request_log_proto_init() }
+func file_request_log_proto_init() {
+	if File_request_log_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_request_log_proto_rawDesc), len(file_request_log_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_request_log_proto_goTypes,
+		DependencyIndexes: file_request_log_proto_depIdxs,
+		MessageInfos:      file_request_log_proto_msgTypes,
+	}.Build()
+	File_request_log_proto = out.File
+	file_request_log_proto_goTypes = nil
+	file_request_log_proto_depIdxs = nil
+}
@@ -3,42 +3,406 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 
 	"github.com/gino/cars-crud/internal/domain"
 	"github.com/gino/cars-crud/pkg/config"
+	"github.com/gino/cars-crud/pkg/logger"
+)
+
+type DropPolicy string
+
+const (
+	DropOldest DropPolicy = "drop-oldest"
+	DropNewest DropPolicy = "drop-newest"
+	Block      DropPolicy = "block"
+)
+
+const writeTimeout = 10 * time.Second
+
+var (
+	ErrBufferFull     = errors.New("producer buffer full")
+	ErrProducerClosed = errors.New("producer closed")
 )
 
 type LogProducer struct {
-	writer *kafka.Writer
+	writer    *kafka.Writer
+	codec     requestLogCodec
+	logger    *slog.Logger
+	buffer    chan kafka.Message
+	batchSize int
+	policy    DropPolicy
+
+	enqueued  atomic.Int64
+	delivered atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+	spooled   atomic.Int64
+	replayed  atomic.Int64
+
+	spool         *Spool
+	drainInterval time.Duration
+
+	mu          sync.Mutex
+	lastError   string
+	lastErrorAt time.Time
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
 }
 
-func NewLogProducer(cfg *config.Config) *LogProducer {
+func NewLogProducer(cfg *config.Config) (*LogProducer, error) {
+	codec, err := codecForEncoding(cfg.KafkaEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := DropPolicy(cfg.KafkaProducerDropPolicy)
+	switch policy {
+	case DropOldest, DropNewest, Block:
+	default:
+		return nil, fmt.Errorf("unknown drop policy %q", cfg.KafkaProducerDropPolicy)
+	}
+
+	bufferSize := max(cfg.KafkaProducerBufferSize, 1)
+	batchSize := max(cfg.KafkaProducerBatchSize, 1)
 	brokers := strings.Split(cfg.KafkaBrokers, ",")
 
 	writer := &kafka.Writer{
-		Addr:     kafka.TCP(brokers...),
-		Topic:    cfg.KafkaTopic,
-		Balancer: &kafka.LeastBytes{},
-		Async:    true,
+		Addr:         kafka.TCP(brokers...),
+		Topic:        cfg.KafkaTopic,
+		Balancer:     &kafka.LeastBytes{},
+		BatchSize:    batchSize,
+		BatchTimeout: 10 * time.Millisecond,
+		WriteTimeout: writeTimeout,
+	}
+
+	p := &LogProducer{
+		writer:    writer,
+		codec:     codec,
+		logger:    slog.Default().With(slog.String("component", "log_producer")),
+		buffer:    make(chan kafka.Message, bufferSize),
+		batchSize: batchSize,
+		policy:    policy,
+		done:      make(chan struct{}),
+	}
+
+	if cfg.KafkaSpoolDir != "" {
+		spool, err := OpenSpool(cfg.KafkaSpoolDir, cfg.KafkaSpoolMaxBytes, cfg.KafkaSpoolSegmentBytes)
+		if err != nil {
+			return nil, fmt.Errorf("open spool: %w", err)
+		}
+		p.spool = spool
+		p.drainInterval = max(cfg.KafkaSpoolDrainInterval, time.Second)
+
+		p.wg.Add(1)
+		go p.drainLoop()
 	}
 
-	return &LogProducer{writer: writer}
+	p.wg.Add(1)
+	go p.run()
+
+	return p, nil
 }
 
+// Publish encodes the log and places it on the local buffer. Delivery to
+// Kafka happens in the background; outcomes are reported through Stats.
 func (p *LogProducer) Publish(ctx context.Context, log domain.RequestLog) error {
-	data, err := json.Marshal(log)
+	data, err := p.codec.Marshal(log)
+	if err != nil {
+		return err
+	}
+
+	event := newEvent(RequestLogEventType, RequestLogSchemaVersion, p.codec.ContentType(), data)
+	msg, err := event.toMessage()
 	if err != nil {
 		return err
 	}
 
-	return p.writer.WriteMessages(ctx, kafka.Message{
-		Value: data,
-	})
+	return p.enqueue(ctx, msg)
+}
+
+// PublishSecurityEvent places a security event on the local buffer. Security
+// events are always JSON encoded.
+func (p *LogProducer) PublishSecurityEvent(ctx context.Context, e domain.SecurityEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	event := newEvent(SecurityEventType, SecurityEventSchemaVersion, jsonContentType, data)
+	msg, err := event.toMessage()
+	if err != nil {
+		return err
+	}
+
+	return p.enqueue(ctx, msg)
+}
+
+func (p *LogProducer) enqueue(ctx context.Context, msg kafka.Message) error {
+	select {
+	case <-p.done:
+		return ErrProducerClosed
+	default:
+	}
+
+	switch p.policy {
+	case Block:
+		select {
+		case p.buffer <- msg:
+		case <-p.done:
+			return ErrProducerClosed
+		case <-ctx.Done():
+			p.dropped.Add(1)
+			return ctx.Err()
+		}
+	case DropNewest:
+		select {
+		case p.buffer <- msg:
+		default:
+			p.dropped.Add(1)
+			return ErrBufferFull
+		}
+	case DropOldest:
+		for {
+			select {
+			case p.buffer <- msg:
+				p.enqueued.Add(1)
+				return nil
+			default:
+			}
+
+			select {
+			case <-p.buffer:
+				p.dropped.Add(1)
+			default:
+			}
+		}
+	}
+
+	p.enqueued.Add(1)
+	return nil
+}
+
+func (p *LogProducer) run() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case msg := <-p.buffer:
+			p.flush(msg)
+		case <-p.done:
+			for {
+				select {
+				case msg := <-p.buffer:
+					p.flush(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *LogProducer) flush(first kafka.Message) {
+	batch := []kafka.Message{first}
+collect:
+	for len(batch) < p.batchSize {
+		select {
+		case msg := <-p.buffer:
+			batch = append(batch, msg)
+		default:
+			break collect
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	p.completion(batch, p.writer.WriteMessages(ctx, batch...))
+}
+
+// completion is called once per batch write with the messages and the write
+// outcome, keeps the delivery counters up to date and spills undelivered
+// messages to the spool when one is configured.
+func (p *LogProducer) completion(messages []kafka.Message, err error) {
+	if err == nil {
+		p.delivered.Add(int64(len(messages)))
+		return
+	}
+
+	undelivered := undeliveredMessages(messages, err)
+	p.failed.Add(int64(len(undelivered)))
+	p.delivered.Add(int64(len(messages) - len(undelivered)))
+	p.recordError(err)
+
+	if p.spool == nil {
+		p.logger.Error("failed to deliver batch", slog.Int("messages", len(messages)), logger.Err(err))
+		return
+	}
+
+	if err := p.spool.Append(undelivered); err != nil {
+		p.logger.Error("failed to spool undelivered messages", slog.Int("messages", len(undelivered)), logger.Err(err))
+		return
+	}
+	p.spooled.Add(int64(len(undelivered)))
+}
+
+func (p *LogProducer) drainLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.drainInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.drainSpool()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// drainSpool replays sealed spool segments to Kafka, oldest first, and stops
+// at the first failure so the next attempt resumes where this one stopped.
+// Messages are keyed by event ID and spread over partitions, so Kafka does
+// not keep them in order; readers sort logs by timestamp instead.
+func (p *LogProducer) drainSpool() {
+	sealed, err := p.spool.Sealed()
+	if err != nil {
+		p.logger.Error("failed to list spool segments", logger.Err(err))
+		return
+	}
+
+	if len(sealed) == 0 {
+		if size, _ := p.spool.Size(); size == 0 {
+			return
+		}
+		if err := p.spool.Seal(); err != nil {
+			p.logger.Error("failed to seal spool segment", logger.Err(err))
+			return
+		}
+		if sealed, err = p.spool.Sealed(); err != nil {
+			p.logger.Error("failed to list spool segments", logger.Err(err))
+			return
+		}
+	}
+
+	for _, seq := range sealed {
+		if !p.replaySegment(seq) {
+			return
+		}
+	}
+}
+
+func (p *LogProducer) replaySegment(seq int) bool {
+	p.spool.Claim(seq)
+	defer p.spool.Release()
+
+	messages, err := p.spool.Read(seq)
+	if err != nil {
+		p.logger.Error("failed to read spool segment", slog.Int("segment", seq), logger.Err(err))
+		return false
+	}
+
+	for start := 0; start < len(messages); start += p.batchSize {
+		end := min(start+p.batchSize, len(messages))
+		batch := messages[start:end]
+
+		ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+		err := p.writer.WriteMessages(ctx, batch...)
+		cancel()
+		if err != nil {
+			undelivered := undeliveredMessages(batch, err)
+			p.replayed.Add(int64(len(batch) - len(undelivered)))
+			p.recordError(err)
+
+			if err := p.spool.Rewrite(seq, slices.Concat(undelivered, messages[end:])); err != nil {
+				p.logger.Error("failed to rewrite spool segment", slog.Int("segment", seq), logger.Err(err))
+			}
+			return false
+		}
+		p.replayed.Add(int64(len(batch)))
+	}
+
+	if err := p.spool.Remove(seq); err != nil && !os.IsNotExist(err) {
+		p.logger.Error("failed to remove spool segment", slog.Int("segment", seq), logger.Err(err))
+	}
+	return true
+}
+
+func (p *LogProducer) recordError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastError = err.Error()
+	p.lastErrorAt = time.Now()
 }
 
+func undeliveredMessages(messages []kafka.Message, err error) []kafka.Message {
+	var writeErrs kafka.WriteErrors
+	if !errors.As(err, &writeErrs) || len(writeErrs) != len(messages) {
+		return messages
+	}
+
+	var undelivered []kafka.Message
+	for i, e := range writeErrs {
+		if e != nil {
+			undelivered = append(undelivered, messages[i])
+		}
+	}
+	return undelivered
+}
+
+func (p *LogProducer) Stats() domain.ProducerStats {
+	stats := domain.ProducerStats{
+		DropPolicy:     string(p.policy),
+		BufferCapacity: cap(p.buffer),
+		Buffered:       len(p.buffer),
+		Enqueued:       p.enqueued.Load(),
+		Delivered:      p.delivered.Load(),
+		Failed:         p.failed.Load(),
+		Dropped:        p.dropped.Load(),
+		Spooled:        p.spooled.Load(),
+		Replayed:       p.replayed.Load(),
+	}
+
+	if p.spool != nil {
+		stats.SpoolBytes, stats.SpoolDropped = p.spool.Size()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.lastError != "" {
+		at := p.lastErrorAt
+		stats.LastError = p.lastError
+		stats.LastErrorAt = &at
+	}
+
+	return stats
+}
+
+// Close stops accepting messages, flushes what is still buffered and closes
+// the underlying writer.
 func (p *LogProducer) Close() error {
+	p.closeOnce.Do(func() { close(p.done) })
+	p.wg.Wait()
+
+	if p.spool != nil {
+		if err := p.spool.Close(); err != nil {
+			p.logger.Error("failed to close spool", logger.Err(err))
+		}
+	}
 	return p.writer.Close()
 }
@@ -0,0 +1,331 @@
+package queue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	spoolPrefix = "spool-"
+	spoolSuffix = ".ndjson"
+)
+
+type spoolHeader struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type spoolRecord struct {
+	Key     []byte        `json:"key,omitempty"`
+	Value   []byte        `json:"value"`
+	Headers []spoolHeader `json:"headers,omitempty"`
+}
+
+// Spool is an append-only on-disk buffer for messages that could not be
+// written to Kafka. It is split into numbered segment files so that replayed
+// segments can be deleted and the total size can be capped by removing the
+// oldest segments first.
+type Spool struct {
+	dir          string
+	maxBytes     int64
+	segmentBytes int64
+
+	mu          sync.Mutex
+	current     *os.File
+	currentSeq  int
+	currentSize int64
+	totalBytes  int64
+	dropped     int64
+	claimed     int // segment being replayed, 0 if none
+}
+
+func OpenSpool(dir string, maxBytes, segmentBytes int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &Spool{dir: dir, maxBytes: maxBytes, segmentBytes: segmentBytes}
+
+	segments, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+	for _, seq := range segments {
+		info, err := os.Stat(s.path(seq))
+		if err != nil {
+			return nil, err
+		}
+		s.totalBytes += info.Size()
+		s.currentSeq = seq
+	}
+
+	// Never append to a segment left over from a previous run; it may end
+	// with a partial record.
+	s.currentSeq++
+	return s, nil
+}
+
+func (s *Spool) Append(messages []kafka.Message) error {
+	var buf bytes.Buffer
+	if err := encodeRecords(&buf, messages); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil && s.currentSize+int64(buf.Len()) > s.segmentBytes {
+		if err := s.sealLocked(); err != nil {
+			return err
+		}
+	}
+
+	if s.current == nil {
+		f, err := os.OpenFile(s.path(s.currentSeq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		s.current = f
+		s.currentSize = 0
+	}
+
+	n, err := s.current.Write(buf.Bytes())
+	s.currentSize += int64(n)
+	s.totalBytes += int64(n)
+	if err != nil {
+		return err
+	}
+
+	return s.enforceCapLocked()
+}
+
+// Seal closes the segment currently being written so it can be replayed.
+func (s *Spool) Seal() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sealLocked()
+}
+
+func (s *Spool) sealLocked() error {
+	if s.current == nil {
+		return nil
+	}
+
+	err := s.current.Close()
+	s.current = nil
+	s.currentSeq++
+	return err
+}
+
+// enforceCapLocked drops the oldest segments until the spool fits its cap,
+// skipping the segment being written and the one being replayed.
+func (s *Spool) enforceCapLocked() error {
+	for s.maxBytes > 0 && s.totalBytes > s.maxBytes {
+		segments, err := s.segments()
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(segments, func(seq int) bool { return seq != s.claimed })
+		if i < 0 || segments[i] == s.currentSeq {
+			return nil
+		}
+
+		if err := s.removeLocked(segments[i], true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Claim marks a sealed segment as being replayed, so the size cap leaves it
+// alone until Release. Only one segment is claimed at a time.
+func (s *Spool) Claim(seq int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claimed = seq
+}
+
+func (s *Spool) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claimed = 0
+}
+
+// Sealed returns the sequence numbers of segments that are no longer being
+// written, oldest first.
+func (s *Spool) Sealed() ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	segments, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := segments[:0]
+	for _, seq := range segments {
+		if s.current == nil || seq != s.currentSeq {
+			sealed = append(sealed, seq)
+		}
+	}
+	return sealed, nil
+}
+
+func (s *Spool) Read(seq int) ([]kafka.Message, error) {
+	f, err := os.Open(s.path(seq))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var messages []kafka.Message
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec spoolRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn write at the end of a segment after a crash.
+			continue
+		}
+		msg := kafka.Message{Key: rec.Key, Value: rec.Value}
+		for _, h := range rec.Headers {
+			msg.Headers = append(msg.Headers, kafka.Header{Key: h.Key, Value: h.Value})
+		}
+		messages = append(messages, msg)
+	}
+	return messages, scanner.Err()
+}
+
+// Rewrite replaces a sealed segment with the given messages, used when a
+// segment was only partially replayed.
+func (s *Spool) Rewrite(seq int, messages []kafka.Message) error {
+	tmp, err := os.CreateTemp(s.dir, "rewrite-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := encodeRecords(tmp, messages); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, err := os.Stat(s.path(seq))
+	if err != nil {
+		return err
+	}
+	after, err := os.Stat(tmp.Name())
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path(seq)); err != nil {
+		return err
+	}
+	s.totalBytes += after.Size() - before.Size()
+	return nil
+}
+
+func (s *Spool) Remove(seq int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.removeLocked(seq, false)
+}
+
+func (s *Spool) removeLocked(seq int, dropped bool) error {
+	path := s.path(seq)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if dropped {
+		if data, err := os.ReadFile(path); err == nil {
+			s.dropped += int64(bytes.Count(data, []byte{'\n'}))
+		}
+	}
+
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	s.totalBytes -= info.Size()
+	return nil
+}
+
+// Size returns the bytes currently on disk and the number of records
+// discarded because the spool exceeded its size cap.
+func (s *Spool) Size() (size int64, dropped int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.totalBytes, s.dropped
+}
+
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil {
+		return nil
+	}
+	err := s.current.Close()
+	s.current = nil
+	return err
+}
+
+func encodeRecords(w io.Writer, messages []kafka.Message) error {
+	enc := json.NewEncoder(w)
+	for _, msg := range messages {
+		rec := spoolRecord{Key: msg.Key, Value: msg.Value}
+		for _, h := range msg.Headers {
+			rec.Headers = append(rec.Headers, spoolHeader{Key: h.Key, Value: h.Value})
+		}
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Spool) path(seq int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s%012d%s", spoolPrefix, seq, spoolSuffix))
+}
+
+func (s *Spool) segments() ([]int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []int
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, spoolPrefix) || !strings.HasSuffix(name, spoolSuffix) {
+			continue
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, spoolPrefix), spoolSuffix))
+		if err != nil {
+			continue
+		}
+		segments = append(segments, seq)
+	}
+
+	sort.Ints(segments)
+	return segments, nil
+}
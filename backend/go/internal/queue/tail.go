@@ -0,0 +1,110 @@
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/pkg/config"
+	"github.com/gino/cars-crud/pkg/logger"
+)
+
+// LogTail feeds the live log stream. The log consumer shares one consumer
+// group across instances, so each of them only sees its own partitions; the
+// tail joins a group of its own instead, so every instance reads every
+// partition and its subscribers see requests served by any instance. It
+// starts at the end of the topic and never commits offsets.
+type LogTail struct {
+	reader *kafka.Reader
+	live   *broadcaster
+}
+
+func NewLogTail(cfg *config.Config) *LogTail {
+	return &LogTail{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     strings.Split(cfg.KafkaBrokers, ","),
+			Topic:       cfg.KafkaTopic,
+			GroupID:     tailGroupID(),
+			StartOffset: kafka.LastOffset,
+			MinBytes:    1,
+			MaxBytes:    10e6,
+		}),
+		live: newBroadcaster(),
+	}
+}
+
+// tailGroupID returns a consumer group unique to this process.
+func tailGroupID() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("log-tail-%s-%s", host, hex.EncodeToString(suffix))
+}
+
+// Start reads request logs in the background until ctx is done.
+func (t *LogTail) Start(ctx context.Context) {
+	l := logger.FromContext(ctx).With(slog.String("component", "log_tail"))
+
+	go func() {
+		for {
+			msg, err := t.reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				l.Error("failed to read message", logger.Err(err))
+				continue
+			}
+
+			reqLog, ok, err := decodeRequestLog(msg)
+			if err != nil {
+				l.Warn("failed to decode message",
+					slog.Int("partition", msg.Partition),
+					slog.Int64("offset", msg.Offset),
+					logger.Err(err),
+				)
+				continue
+			}
+			if ok {
+				t.live.publish(reqLog)
+			}
+		}
+	}()
+}
+
+// decodeRequestLog decodes msg, reporting false for events other than
+// request logs.
+func decodeRequestLog(msg kafka.Message) (domain.RequestLog, bool, error) {
+	var reqLog domain.RequestLog
+
+	event, err := decodeEvent(msg)
+	if err != nil || event.Type != RequestLogEventType {
+		return reqLog, false, err
+	}
+
+	codec, err := codecForContentType(event.DataContentType)
+	if err != nil {
+		return reqLog, false, err
+	}
+	if err := codec.Unmarshal(event.Data, &reqLog); err != nil {
+		return reqLog, false, fmt.Errorf("unmarshal request log: %w", err)
+	}
+	return reqLog, true, nil
+}
+
+// Subscribe returns a channel receiving every request log published to the
+// topic from now on, and a function to cancel the subscription.
+func (t *LogTail) Subscribe() (<-chan domain.RequestLog, func()) {
+	return t.live.subscribe()
+}
+
+func (t *LogTail) Close() error {
+	return t.reader.Close()
+}
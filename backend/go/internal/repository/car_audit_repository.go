@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/gino/cars-crud/internal/domain"
+)
+
+type CarAuditRepository interface {
+	Create(ctx context.Context, audit *domain.CarAudit) error
+	ListByCar(ctx context.Context, carID uuid.UUID, offset, limit int) ([]domain.CarAudit, int64, error)
+}
@@ -10,7 +10,11 @@ import (
 type CarRepository interface {
 	Create(ctx context.Context, car *domain.Car) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Car, error)
+	// GetByIDForUpdate reads a car and locks its row until the surrounding
+	// transaction ends.
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.Car, error)
 	GetAll(ctx context.Context, offset, limit int) ([]domain.Car, int64, error)
 	Update(ctx context.Context, car *domain.Car) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	Restore(ctx context.Context, id uuid.UUID) (*domain.Car, error)
 }
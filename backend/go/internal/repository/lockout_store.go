@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/gino/cars-crud/internal/domain"
+)
+
+// LockoutStore counts failed authentication attempts per subject and keeps
+// the lockouts they cause.
+type LockoutStore interface {
+	// Fail counts a failed attempt and locks subject out once the policy
+	// limit is reached. It returns the failures counted in the window and
+	// the lockout it started, if any.
+	Fail(ctx context.Context, subject string, policy domain.LockoutPolicy) (int, *domain.Lockout, error)
+	// Locked returns the active lockout of subject, or nil.
+	Locked(ctx context.Context, subject string) (*domain.Lockout, error)
+	// Reset clears the failures counted for subject.
+	Reset(ctx context.Context, subject string) error
+	// Unlock ends the lockout of subject and forgets its history.
+	Unlock(ctx context.Context, subject string) error
+	List(ctx context.Context) ([]domain.Lockout, error)
+}
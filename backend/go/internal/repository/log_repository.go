@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/gino/cars-crud/internal/domain"
 )
 
 type LogRepository interface {
-	GetAll(ctx context.Context, offset, limit int) ([]domain.RequestLog, int64, error)
+	GetAll(ctx context.Context, filter domain.LogFilter, offset, limit int) ([]domain.RequestLog, int64, error)
+	Stats(ctx context.Context, query domain.LogStatsQuery) ([]domain.LogStatsBucket, error)
+	ForEach(ctx context.Context, filter domain.LogFilter, fn func(domain.RequestLog) error) error
+	StoredSince(ctx context.Context, filter domain.LogFilter, since time.Time) (bool, error)
 }
@@ -0,0 +1,53 @@
+// Package memory holds process-local implementations of repository
+// interfaces, used when a shared store is unavailable.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+)
+
+const sweepInterval = time.Minute
+
+// rateLimiter keeps each key's theoretical arrival time in a map. Limits are
+// per process, so with several instances a client gets the limit once per
+// instance.
+type rateLimiter struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewRateLimiter() repository.RateLimiter {
+	return &rateLimiter{tats: make(map[string]time.Time)}
+}
+
+func (l *rateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+
+	result, tat := domain.EvaluateRateLimit(limit, l.tats[key], now)
+	l.tats[key] = tat
+	return result, nil
+}
+
+// sweep drops keys whose limit has fully refilled, keeping the map bounded
+// by the number of recently active clients.
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, tat := range l.tats {
+		if !tat.After(now) {
+			delete(l.tats, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -2,8 +2,11 @@ package mongo
 
 import (
 	"context"
+	"regexp"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
@@ -19,8 +22,83 @@ func NewLogRepository(collection *mongo.Collection) repository.LogRepository {
 	return &logRepository{collection: collection}
 }
 
-func (r *logRepository) GetAll(ctx context.Context, offset, limit int) ([]domain.RequestLog, int64, error) {
-	total, err := r.collection.CountDocuments(ctx, bson.M{})
+// EnsureLogIndexes creates the compound indexes backing the log filters.
+// Every index ends in timestamp so filtered queries stay sorted without an
+// in-memory sort.
+func EnsureLogIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "path", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "route", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "method", Value: 1}, {Key: "path", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "status_code", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "ip", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "duration_ms", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	return err
+}
+
+const ttlIndexName = "timestamp_ttl"
+
+// EnsureLogRetention keeps a TTL index on timestamp in line with the
+// configured retention. A zero retention removes the index so logs are kept
+// forever.
+func EnsureLogRetention(ctx context.Context, collection *mongo.Collection, retention time.Duration) error {
+	var existing *int32
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var idx struct {
+			Name               string `bson:"name"`
+			ExpireAfterSeconds *int32 `bson:"expireAfterSeconds"`
+		}
+		if err := cursor.Decode(&idx); err != nil {
+			return err
+		}
+		if idx.Name == ttlIndexName {
+			existing = idx.ExpireAfterSeconds
+			if existing == nil {
+				existing = new(int32)
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	seconds := int32(retention / time.Second)
+	switch {
+	case retention <= 0:
+		if existing != nil {
+			_, err = collection.Indexes().DropOne(ctx, ttlIndexName)
+		}
+		return err
+	case existing == nil:
+		_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "timestamp", Value: 1}},
+			Options: options.Index().SetName(ttlIndexName).SetExpireAfterSeconds(seconds),
+		})
+		return err
+	case *existing != seconds:
+		return collection.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection.Name()},
+			{Key: "index", Value: bson.D{
+				{Key: "name", Value: ttlIndexName},
+				{Key: "expireAfterSeconds", Value: seconds},
+			}},
+		}).Err()
+	}
+	return nil
+}
+
+func (r *logRepository) GetAll(ctx context.Context, filter domain.LogFilter, offset, limit int) ([]domain.RequestLog, int64, error) {
+	query := buildLogFilter(filter)
+
+	total, err := r.collection.CountDocuments(ctx, query)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -30,7 +108,7 @@ func (r *logRepository) GetAll(ctx context.Context, offset, limit int) ([]domain
 		SetLimit(int64(limit)).
 		SetSort(bson.D{{Key: "timestamp", Value: -1}})
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	cursor, err := r.collection.Find(ctx, query, opts)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -43,3 +121,163 @@ func (r *logRepository) GetAll(ctx context.Context, offset, limit int) ([]domain
 
 	return logs, total, nil
 }
+
+// Stats groups matching logs by method, route pattern and time bucket and computes
+// request counts, server error (5xx) rates and latency percentiles.
+func (r *logRepository) Stats(ctx context.Context, query domain.LogStatsQuery) ([]domain.LogStatsBucket, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: buildLogFilter(query.Filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"method": "$method",
+				"route":  bson.M{"$ifNull": bson.A{"$route", "$path"}},
+				"bucket": bson.M{"$dateTrunc": bson.M{"date": "$timestamp", "unit": query.Interval}},
+			},
+			"count":  bson.M{"$sum": 1},
+			"errors": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$status_code", 500}}, 1, 0}}},
+			"percentiles": bson.M{"$percentile": bson.M{
+				"input":  "$duration_ms",
+				"p":      bson.A{0.5, 0.95, 0.99},
+				"method": "approximate",
+			}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":        0,
+			"bucket":     "$_id.bucket",
+			"method":     "$_id.method",
+			"route":      "$_id.route",
+			"count":      1,
+			"errors":     1,
+			"error_rate": bson.M{"$divide": bson.A{"$errors", "$count"}},
+			"p50":        bson.M{"$arrayElemAt": bson.A{"$percentiles", 0}},
+			"p95":        bson.M{"$arrayElemAt": bson.A{"$percentiles", 1}},
+			"p99":        bson.M{"$arrayElemAt": bson.A{"$percentiles", 2}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "bucket", Value: 1}, {Key: "route", Value: 1}, {Key: "method", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	stats := []domain.LogStatsBucket{}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// ForEach streams matching logs from a cursor in timestamp order, so large
+// ranges never have to fit in memory.
+func (r *logRepository) ForEach(ctx context.Context, filter domain.LogFilter, fn func(domain.RequestLog) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, buildLogFilter(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var entry domain.RequestLog
+		if err := cursor.Decode(&entry); err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// StoredSince reports whether any matching log was inserted at or after
+// since, going by the insertion time in its ObjectID. The ID only has second
+// precision, so since is rounded down to the second.
+func (r *logRepository) StoredSince(ctx context.Context, filter domain.LogFilter, since time.Time) (bool, error) {
+	query := buildLogFilter(filter)
+	query["_id"] = bson.M{"$gte": primitive.NewObjectIDFromTimestamp(since)}
+
+	err := r.collection.FindOne(ctx, query, options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func buildLogFilter(f domain.LogFilter) bson.M {
+	query := bson.M{}
+
+	if f.Method != "" {
+		query["method"] = f.Method
+	}
+
+	if f.Route != "" {
+		query["route"] = f.Route
+	}
+
+	switch {
+	case f.Path != "":
+		query["path"] = f.Path
+	case f.PathPrefix != "":
+		query["path"] = bson.M{"$regex": "^" + regexp.QuoteMeta(f.PathPrefix)}
+	}
+
+	if f.StatusCode != 0 {
+		query["status_code"] = f.StatusCode
+	} else if status := rangeFilter(f.StatusMin, f.StatusMax); status != nil {
+		query["status_code"] = status
+	}
+
+	duration := bson.M{}
+	if f.MinDurationMs != nil {
+		duration["$gte"] = *f.MinDurationMs
+	}
+	if f.MaxDurationMs != nil {
+		duration["$lte"] = *f.MaxDurationMs
+	}
+	if len(duration) > 0 {
+		query["duration_ms"] = duration
+	}
+
+	if f.IP != "" {
+		query["ip"] = f.IP
+	}
+
+	if f.Principal != "" {
+		query["principal"] = f.Principal
+	}
+
+	if f.UserAgent != "" {
+		query["user_agent"] = bson.M{"$regex": regexp.QuoteMeta(f.UserAgent), "$options": "i"}
+	}
+
+	timestamp := bson.M{}
+	if f.From != nil {
+		timestamp["$gte"] = *f.From
+	}
+	if f.To != nil {
+		timestamp["$lt"] = *f.To
+	}
+	if len(timestamp) > 0 {
+		query["timestamp"] = timestamp
+	}
+
+	return query
+}
+
+func rangeFilter(min, max int) bson.M {
+	r := bson.M{}
+	if min != 0 {
+		r["$gte"] = min
+	}
+	if max != 0 {
+		r["$lte"] = max
+	}
+	if len(r) == 0 {
+		return nil
+	}
+	return r
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) repository.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	return conn(ctx, r.db).Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := conn(ctx, r.db).First(&key, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := conn(ctx, r.db).First(&key, "prefix = ?", prefix).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetAll(ctx context.Context, offset, limit int) ([]domain.APIKey, int64, error) {
+	var keys []domain.APIKey
+	var total int64
+
+	if err := conn(ctx, r.db).Model(&domain.APIKey{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := conn(ctx, r.db).Offset(offset).Limit(limit).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return keys, total, nil
+}
+
+// Revoke marks the key revoked. Revoking an already revoked key keeps the
+// original revocation time.
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := conn(ctx, r.db).First(&key, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if key.Revoked {
+		return &key, nil
+	}
+
+	key.Revoked, key.RevokedAt = true, &at
+	err := conn(ctx, r.db).Model(&key).Updates(map[string]interface{}{
+		"revoked":    true,
+		"revoked_at": at,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return conn(ctx, r.db).Model(&domain.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+)
+
+type carAuditRepository struct {
+	db *gorm.DB
+}
+
+func NewCarAuditRepository(db *gorm.DB) repository.CarAuditRepository {
+	return &carAuditRepository{db: db}
+}
+
+func (r *carAuditRepository) Create(ctx context.Context, audit *domain.CarAudit) error {
+	return conn(ctx, r.db).Create(audit).Error
+}
+
+func (r *carAuditRepository) ListByCar(ctx context.Context, carID uuid.UUID, offset, limit int) ([]domain.CarAudit, int64, error) {
+	var audits []domain.CarAudit
+	var total int64
+
+	query := conn(ctx, r.db).Model(&domain.CarAudit{}).Where("car_id = ?", carID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&audits).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return audits, total, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/gino/cars-crud/internal/domain"
 	"github.com/gino/cars-crud/internal/repository"
@@ -19,12 +20,20 @@ func NewCarRepository(db *gorm.DB) repository.CarRepository {
 }
 
 func (r *carRepository) Create(ctx context.Context, car *domain.Car) error {
-	return r.db.WithContext(ctx).Create(car).Error
+	return conn(ctx, r.db).Create(car).Error
 }
 
 func (r *carRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Car, error) {
 	var car domain.Car
-	if err := r.db.WithContext(ctx).First(&car, "id = ?", id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&car, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &car, nil
+}
+
+func (r *carRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.Car, error) {
+	var car domain.Car
+	if err := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).First(&car, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &car, nil
@@ -34,11 +43,11 @@ func (r *carRepository) GetAll(ctx context.Context, offset, limit int) ([]domain
 	var cars []domain.Car
 	var total int64
 
-	if err := r.db.WithContext(ctx).Model(&domain.Car{}).Count(&total).Error; err != nil {
+	if err := conn(ctx, r.db).Model(&domain.Car{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	if err := r.db.WithContext(ctx).Offset(offset).Limit(limit).Order("created_at DESC").Find(&cars).Error; err != nil {
+	if err := conn(ctx, r.db).Offset(offset).Limit(limit).Order("created_at DESC").Find(&cars).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -46,9 +55,23 @@ func (r *carRepository) GetAll(ctx context.Context, offset, limit int) ([]domain
 }
 
 func (r *carRepository) Update(ctx context.Context, car *domain.Car) error {
-	return r.db.WithContext(ctx).Save(car).Error
+	return conn(ctx, r.db).Save(car).Error
 }
 
 func (r *carRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&domain.Car{}, "id = ?", id).Error
+	return conn(ctx, r.db).Delete(&domain.Car{}, "id = ?", id).Error
+}
+
+// Restore clears the soft delete of a car and returns it.
+func (r *carRepository) Restore(ctx context.Context, id uuid.UUID) (*domain.Car, error) {
+	res := conn(ctx, r.db).Unscoped().Model(&domain.Car{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.GetByID(ctx, id)
 }
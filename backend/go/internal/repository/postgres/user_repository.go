@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+)
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) repository.UserRepository {
+	return &userRepository{db: db}
+}
+
+// Create fails with domain.ErrUsernameTaken when the username is in use.
+func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
+	err := conn(ctx, r.db).Create(user).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domain.ErrUsernameTaken
+	}
+	return err
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	var user domain.User
+	if err := conn(ctx, r.db).First(&user, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	var user domain.User
+	if err := conn(ctx, r.db).First(&user, "username = ?", username).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetAll(ctx context.Context, offset, limit int) ([]domain.User, int64, error) {
+	var users []domain.User
+	var total int64
+
+	if err := conn(ctx, r.db).Model(&domain.User{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := conn(ctx, r.db).Offset(offset).Limit(limit).Order("username").Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
+	return conn(ctx, r.db).Save(user).Error
+}
+
+// Delete removes the user for good. It fails with gorm.ErrRecordNotFound
+// when there is no such user.
+func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	res := conn(ctx, r.db).Delete(&domain.User{}, "id = ?", id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) TouchLastLogin(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return conn(ctx, r.db).Model(&domain.User{}).Where("id = ?", id).Update("last_login_at", at).Error
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/gino/cars-crud/internal/domain"
+)
+
+// RateLimiter counts a request for key against limit.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitResult, error)
+}
@@ -0,0 +1,139 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+)
+
+const (
+	failuresPrefix     = "auth:failures:"
+	lockoutPrefix      = "auth:lockout:"
+	lockoutLevelPrefix = "auth:lockout-level:"
+)
+
+// failScript counts a failure and, once the limit is reached, starts a
+// lockout whose duration doubles with each level. The level outlives the
+// lockout by the reset period so repeat offenders back off further.
+var failScript = goredis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if n < tonumber(ARGV[1]) then
+	return {n, 0, 0}
+end
+redis.call('DEL', KEYS[1])
+local level = redis.call('INCR', KEYS[3])
+local d = math.min(tonumber(ARGV[3]) * 2 ^ (level - 1), tonumber(ARGV[4]))
+d = math.floor(d)
+redis.call('PEXPIRE', KEYS[3], tonumber(ARGV[5]) + d)
+redis.call('SET', KEYS[2], level, 'PX', d)
+return {n, level, d}
+`)
+
+type lockoutStore struct {
+	client *goredis.Client
+}
+
+func NewLockoutStore(client *goredis.Client) repository.LockoutStore {
+	return &lockoutStore{client: client}
+}
+
+func (s *lockoutStore) Fail(ctx context.Context, subject string, policy domain.LockoutPolicy) (int, *domain.Lockout, error) {
+	res, err := failScript.Run(ctx, s.client,
+		[]string{failuresPrefix + subject, lockoutPrefix + subject, lockoutLevelPrefix + subject},
+		policy.MaxFailures,
+		policy.Window.Milliseconds(),
+		policy.Duration.Milliseconds(),
+		policy.MaxDuration.Milliseconds(),
+		policy.ResetAfter.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(res) != 3 {
+		return 0, nil, fmt.Errorf("unexpected fail result %v", res)
+	}
+
+	failures := int(res[0])
+	if res[1] == 0 {
+		return failures, nil, nil
+	}
+	return failures, &domain.Lockout{
+		Subject:   subject,
+		Level:     int(res[1]),
+		ExpiresAt: time.Now().Add(time.Duration(res[2]) * time.Millisecond),
+	}, nil
+}
+
+func (s *lockoutStore) Locked(ctx context.Context, subject string) (*domain.Lockout, error) {
+	return s.lockout(ctx, subject)
+}
+
+func (s *lockoutStore) Reset(ctx context.Context, subject string) error {
+	return s.client.Del(ctx, failuresPrefix+subject).Err()
+}
+
+func (s *lockoutStore) Unlock(ctx context.Context, subject string) error {
+	return s.client.Del(ctx, failuresPrefix+subject, lockoutPrefix+subject, lockoutLevelPrefix+subject).Err()
+}
+
+// List returns every active lockout, soonest to expire first.
+func (s *lockoutStore) List(ctx context.Context) ([]domain.Lockout, error) {
+	lockouts := []domain.Lockout{}
+	iter := s.client.Scan(ctx, 0, lockoutPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		lockout, err := s.lockout(ctx, strings.TrimPrefix(iter.Val(), lockoutPrefix))
+		if err != nil {
+			return nil, err
+		}
+		if lockout != nil {
+			lockouts = append(lockouts, *lockout)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(lockouts, func(i, j int) bool {
+		return lockouts[i].ExpiresAt.Before(lockouts[j].ExpiresAt)
+	})
+	return lockouts, nil
+}
+
+func (s *lockoutStore) lockout(ctx context.Context, subject string) (*domain.Lockout, error) {
+	var (
+		level *goredis.StringCmd
+		ttl   *goredis.DurationCmd
+	)
+	_, err := s.client.Pipelined(ctx, func(p goredis.Pipeliner) error {
+		level = p.Get(ctx, lockoutPrefix+subject)
+		ttl = p.PTTL(ctx, lockoutPrefix+subject)
+		return nil
+	})
+	if err == goredis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ttl.Val() <= 0 {
+		return nil, nil
+	}
+
+	n, _ := strconv.Atoi(level.Val())
+	return &domain.Lockout{
+		Subject:   subject,
+		Level:     n,
+		ExpiresAt: time.Now().Add(ttl.Val()),
+	}, nil
+}
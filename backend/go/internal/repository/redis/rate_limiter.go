@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+)
+
+const rateLimitPrefix = "ratelimit:"
+
+// rateLimitScript implements domain.EvaluateRateLimit atomically, storing
+// the theoretical arrival time in microseconds. The value is formatted with
+// %d because Lua would otherwise print it with 14 significant digits. It uses the Redis clock so
+// every API instance agrees on the time.
+var rateLimitScript = goredis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local next = tat + interval
+local allow_at = next - window
+if allow_at > now then
+	return {0, tat - now, allow_at - now}
+end
+redis.call('SET', KEYS[1], string.format('%d', next), 'PX', math.ceil((next - now) / 1000))
+return {1, next - now, 0}
+`)
+
+type rateLimiter struct {
+	client *goredis.Client
+}
+
+func NewRateLimiter(client *goredis.Client) repository.RateLimiter {
+	return &rateLimiter{client: client}
+}
+
+func (l *rateLimiter) Allow(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	interval := limit.Interval()
+	res, err := rateLimitScript.Run(ctx, l.client, []string{rateLimitPrefix + key},
+		interval.Microseconds(), limit.Window.Microseconds(),
+	).Int64Slice()
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+	if len(res) != 3 {
+		return domain.RateLimitResult{}, fmt.Errorf("unexpected rate limit result %v", res)
+	}
+
+	result := domain.RateLimitResult{
+		Allowed:    res[0] == 1,
+		Limit:      limit.Requests,
+		Reset:      time.Duration(res[1]) * time.Microsecond,
+		RetryAfter: time.Duration(res[2]) * time.Microsecond,
+	}
+	if result.Allowed {
+		result.Remaining = int((limit.Window - result.Reset) / interval)
+	}
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+)
+
+const (
+	refreshPrefix        = "auth:refresh:"
+	usedRefreshPrefix    = "auth:refresh-used:"
+	revokedTokenPrefix   = "auth:revoked:jti:"
+	revokedSessionPrefix = "auth:revoked:session:"
+)
+
+// consumeScript moves a refresh session to the "used" key, keeping its TTL,
+// so a second use can be told apart from an unknown token.
+var consumeScript = goredis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if v then
+	local ttl = redis.call('PTTL', KEYS[1])
+	redis.call('DEL', KEYS[1])
+	if ttl > 0 then
+		redis.call('SET', KEYS[2], v, 'PX', ttl)
+	end
+	return {'ok', v}
+end
+local used = redis.call('GET', KEYS[2])
+if used then
+	return {'reused', used}
+end
+return {'missing', ''}
+`)
+
+type tokenStore struct {
+	client *goredis.Client
+}
+
+func NewTokenStore(client *goredis.Client) repository.TokenStore {
+	return &tokenStore{client: client}
+}
+
+func (s *tokenStore) SaveRefresh(ctx context.Context, hash string, session domain.RefreshSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, refreshPrefix+hash, data, time.Until(session.ExpiresAt)).Err()
+}
+
+func (s *tokenStore) ConsumeRefresh(ctx context.Context, hash string) (*domain.RefreshSession, error) {
+	res, err := consumeScript.Run(ctx, s.client, []string{refreshPrefix + hash, usedRefreshPrefix + hash}).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != 2 {
+		return nil, fmt.Errorf("unexpected consume result %v", res)
+	}
+
+	switch res[0] {
+	case "ok", "reused":
+		var session domain.RefreshSession
+		if err := json.Unmarshal([]byte(res[1]), &session); err != nil {
+			return nil, err
+		}
+		if res[0] == "reused" {
+			return &session, domain.ErrRefreshTokenReused
+		}
+		return &session, nil
+	default:
+		return nil, domain.ErrInvalidRefreshToken
+	}
+}
+
+func (s *tokenStore) RevokeSession(ctx context.Context, session string, ttl time.Duration) error {
+	return s.client.Set(ctx, revokedSessionPrefix+session, 1, ttl).Err()
+}
+
+func (s *tokenStore) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
+	return s.client.Set(ctx, revokedTokenPrefix+jti, 1, ttl).Err()
+}
+
+func (s *tokenStore) Revoked(ctx context.Context, jti, session string) (bool, error) {
+	var keys []string
+	if jti != "" {
+		keys = append(keys, revokedTokenPrefix+jti)
+	}
+	if session != "" {
+		keys = append(keys, revokedSessionPrefix+session)
+	}
+	if len(keys) == 0 {
+		return false, nil
+	}
+	n, err := s.client.Exists(ctx, keys...).Result()
+	return n > 0, err
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gino/cars-crud/internal/domain"
+)
+
+// TokenStore keeps refresh sessions and revocations. Keys are token hashes
+// and IDs, never the tokens themselves.
+type TokenStore interface {
+	SaveRefresh(ctx context.Context, hash string, session domain.RefreshSession) error
+	// ConsumeRefresh removes and returns the session for hash. A hash that
+	// was already consumed returns its session with domain.ErrRefreshTokenReused.
+	ConsumeRefresh(ctx context.Context, hash string) (*domain.RefreshSession, error)
+	RevokeSession(ctx context.Context, session string, ttl time.Duration) error
+	RevokeToken(ctx context.Context, jti string, ttl time.Duration) error
+	// Revoked reports whether the token ID or its session has been revoked.
+	Revoked(ctx context.Context, jti, session string) (bool, error)
+}
@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+	"github.com/gino/cars-crud/pkg/logger"
+)
+
+const apiKeyScheme = "ck_"
+
+// APIKeyUsecase issues and verifies managed API keys. Keys have the form
+// "ck_<prefix>_<secret>"; the prefix locates the stored hash and the whole
+// key is compared by hash in constant time.
+type APIKeyUsecase struct {
+	repo      repository.APIKeyRepository
+	bootstrap []byte
+}
+
+// NewAPIKeyUsecase creates the usecase. A non-empty bootstrapKey is accepted
+// in addition to the stored keys so a fresh install can create its first
+// managed key.
+func NewAPIKeyUsecase(repo repository.APIKeyRepository, bootstrapKey string) *APIKeyUsecase {
+	u := &APIKeyUsecase{repo: repo}
+	if bootstrapKey != "" {
+		u.bootstrap = hashAPIKey(bootstrapKey)
+	}
+	return u
+}
+
+func (u *APIKeyUsecase) Create(ctx context.Context, req domain.CreateAPIKeyRequest) (*domain.CreateAPIKeyResponse, error) {
+	prefix, err := randomHex(6)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := apiKeyScheme + prefix + "_" + secret
+	key := domain.APIKey{
+		Name:      req.Name,
+		Owner:     req.Owner,
+		Scopes:    req.Scopes,
+		Prefix:    apiKeyScheme + prefix,
+		Hash:      hex.EncodeToString(hashAPIKey(plaintext)),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := u.repo.Create(ctx, &key); err != nil {
+		return nil, err
+	}
+
+	return &domain.CreateAPIKeyResponse{APIKey: key, Key: plaintext}, nil
+}
+
+func (u *APIKeyUsecase) GetAll(ctx context.Context, offset, limit int) ([]domain.APIKey, int64, error) {
+	return u.repo.GetAll(ctx, offset, limit)
+}
+
+func (u *APIKeyUsecase) Revoke(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	return u.repo.Revoke(ctx, id, time.Now())
+}
+
+// Authenticate returns the key matching plaintext, or domain.ErrInvalidAPIKey
+// when it is unknown, revoked or expired.
+func (u *APIKeyUsecase) Authenticate(ctx context.Context, plaintext string) (*domain.APIKey, error) {
+	hash := hashAPIKey(plaintext)
+
+	prefix, ok := apiKeyPrefix(plaintext)
+	if !ok {
+		if u.bootstrap != nil && subtle.ConstantTimeCompare(hash, u.bootstrap) == 1 {
+			return u.bootstrapKey(), nil
+		}
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	key, err := u.repo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := hex.DecodeString(key.Hash)
+	if err != nil || subtle.ConstantTimeCompare(hash, stored) != 1 {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if !key.Active(now) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	if err := u.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+		logger.FromContext(ctx).Warn("failed to record api key use", slog.String("key_id", key.ID.String()), logger.Err(err))
+	} else {
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
+
+// Resolve returns the current principal of a token subject, failing with
+// domain.ErrInvalidAPIKey once the key has been revoked or has expired.
+func (u *APIKeyUsecase) Resolve(ctx context.Context, subject string) (*domain.Principal, error) {
+	if subject == domain.BootstrapKeyName {
+		if u.bootstrap == nil {
+			return nil, domain.ErrInvalidAPIKey
+		}
+		p := u.bootstrapKey().Principal()
+		return &p, nil
+	}
+
+	id, err := uuid.Parse(subject)
+	if err != nil {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	key, err := u.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active(time.Now()) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	p := key.Principal()
+	return &p, nil
+}
+
+func (u *APIKeyUsecase) bootstrapKey() *domain.APIKey {
+	return &domain.APIKey{Name: domain.BootstrapKeyName, Scopes: []string{domain.ScopeAdmin}}
+}
+
+// apiKeyPrefix returns the lookup prefix of a managed key, "ck_<prefix>".
+func apiKeyPrefix(plaintext string) (string, bool) {
+	rest, ok := strings.CutPrefix(plaintext, apiKeyScheme)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || secret == "" {
+		return "", false
+	}
+	return apiKeyScheme + prefix, true
+}
+
+func hashAPIKey(plaintext string) []byte {
+	sum := sha256.Sum256([]byte(plaintext))
+	return sum[:]
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -3,23 +3,34 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 
 	"github.com/gino/cars-crud/internal/cache"
 	"github.com/gino/cars-crud/internal/domain"
 	"github.com/gino/cars-crud/internal/repository"
+	"github.com/gino/cars-crud/pkg/logger"
 )
 
 type CarUsecase struct {
-	repo  repository.CarRepository
-	cache *cache.RedisCache
+	repo   repository.CarRepository
+	audits repository.CarAuditRepository
+	tx     repository.Transactor
+	cache  *cache.RedisCache
 }
 
-func NewCarUsecase(repo repository.CarRepository, cache *cache.RedisCache) *CarUsecase {
-	return &CarUsecase{repo: repo, cache: cache}
+func NewCarUsecase(
+	repo repository.CarRepository,
+	audits repository.CarAuditRepository,
+	tx repository.Transactor,
+	cache *cache.RedisCache,
+) *CarUsecase {
+	return &CarUsecase{repo: repo, audits: audits, tx: tx, cache: cache}
 }
 
 func (u *CarUsecase) Create(ctx context.Context, req domain.CreateCarRequest) (*domain.Car, error) {
@@ -31,11 +42,17 @@ func (u *CarUsecase) Create(ctx context.Context, req domain.CreateCarRequest) (*
 		Price: req.Price,
 	}
 
-	if err := u.repo.Create(ctx, car); err != nil {
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.Create(ctx, car); err != nil {
+			return err
+		}
+		return u.audit(ctx, car.ID, domain.AuditCreate, domain.DiffCars(nil, car))
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	_ = u.cache.DeleteByPattern(ctx, "cars:list:*")
+	u.invalidate(ctx)
 	return car, nil
 }
 
@@ -58,7 +75,7 @@ func (u *CarUsecase) GetByID(ctx context.Context, id uuid.UUID) (*domain.Car, er
 	}
 
 	if data, err := json.Marshal(car); err == nil {
-		_ = u.cache.Set(ctx, key, string(data))
+		u.store(ctx, key, string(data))
 	}
 
 	return car, nil
@@ -78,6 +95,8 @@ func (u *CarUsecase) GetAll(ctx context.Context, offset, limit int) ([]domain.Ca
 		if json.Unmarshal([]byte(cached), &lc) == nil {
 			return lc.Cars, lc.Total, nil
 		}
+	} else if err != redis.Nil {
+		logger.FromContext(ctx).Warn("car list cache read failed", slog.String("key", key), logger.Err(err))
 	}
 
 	cars, total, err := u.repo.GetAll(ctx, offset, limit)
@@ -86,51 +105,130 @@ func (u *CarUsecase) GetAll(ctx context.Context, offset, limit int) ([]domain.Ca
 	}
 
 	if data, err := json.Marshal(listCache{Cars: cars, Total: total}); err == nil {
-		_ = u.cache.Set(ctx, key, string(data))
+		u.store(ctx, key, string(data))
 	}
 
 	return cars, total, nil
 }
 
+// Update applies req to the car. The row is read and locked in the same
+// transaction as the write, so concurrent updates record accurate diffs.
 func (u *CarUsecase) Update(ctx context.Context, id uuid.UUID, req domain.UpdateCarRequest) (*domain.Car, error) {
-	car, err := u.repo.GetByID(ctx, id)
-	if err != nil {
-		return nil, err
-	}
+	var car *domain.Car
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if car, err = u.repo.GetByIDForUpdate(ctx, id); err != nil {
+			return err
+		}
+		before := *car
 
-	if req.Brand != nil {
-		car.Brand = *req.Brand
-	}
-	if req.Model != nil {
-		car.Model = *req.Model
-	}
-	if req.Year != nil {
-		car.Year = *req.Year
-	}
-	if req.Color != nil {
-		car.Color = *req.Color
-	}
-	if req.Price != nil {
-		car.Price = *req.Price
-	}
+		if req.Brand != nil {
+			car.Brand = *req.Brand
+		}
+		if req.Model != nil {
+			car.Model = *req.Model
+		}
+		if req.Year != nil {
+			car.Year = *req.Year
+		}
+		if req.Color != nil {
+			car.Color = *req.Color
+		}
+		if req.Price != nil {
+			car.Price = *req.Price
+		}
 
-	if err := u.repo.Update(ctx, car); err != nil {
+		if err := u.repo.Update(ctx, car); err != nil {
+			return err
+		}
+		if changes := domain.DiffCars(&before, car); len(changes) > 0 {
+			return u.audit(ctx, id, domain.AuditUpdate, changes)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	_ = u.cache.Delete(ctx, fmt.Sprintf("cars:%s", id.String()))
-	_ = u.cache.DeleteByPattern(ctx, "cars:list:*")
+	u.invalidate(ctx, fmt.Sprintf("cars:%s", id.String()))
 
 	return car, nil
 }
 
+// Delete soft-deletes the car. Deleting an unknown or already deleted car
+// is a no-op and records nothing.
 func (u *CarUsecase) Delete(ctx context.Context, id uuid.UUID) error {
-	if err := u.repo.Delete(ctx, id); err != nil {
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		car, err := u.repo.GetByIDForUpdate(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := u.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return u.audit(ctx, id, domain.AuditDelete, domain.DiffCars(car, nil))
+	})
+	if err != nil {
 		return err
 	}
 
-	_ = u.cache.Delete(ctx, fmt.Sprintf("cars:%s", id.String()))
-	_ = u.cache.DeleteByPattern(ctx, "cars:list:*")
+	u.invalidate(ctx, fmt.Sprintf("cars:%s", id.String()))
 
 	return nil
 }
+
+// Restore brings back a deleted car.
+func (u *CarUsecase) Restore(ctx context.Context, id uuid.UUID) (*domain.Car, error) {
+	var car *domain.Car
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if car, err = u.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		return u.audit(ctx, id, domain.AuditRestore, domain.DiffCars(nil, car))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.invalidate(ctx, fmt.Sprintf("cars:%s", id.String()))
+
+	return car, nil
+}
+
+// History returns the audit trail of a car, newest first. It is available
+// for deleted cars too.
+func (u *CarUsecase) History(ctx context.Context, id uuid.UUID, offset, limit int) ([]domain.CarAudit, int64, error) {
+	return u.audits.ListByCar(ctx, id, offset, limit)
+}
+
+func (u *CarUsecase) audit(ctx context.Context, id uuid.UUID, action domain.AuditAction, changes domain.AuditChanges) error {
+	return u.audits.Create(ctx, &domain.CarAudit{
+		CarID:   id,
+		Action:  action,
+		Actor:   domain.ActorFromContext(ctx),
+		Changes: changes,
+	})
+}
+
+func (u *CarUsecase) store(ctx context.Context, key, value string) {
+	if err := u.cache.Set(ctx, key, value); err != nil {
+		logger.FromContext(ctx).Warn("car cache write failed", slog.String("key", key), logger.Err(err))
+	}
+}
+
+// invalidate drops the given keys and every cached list page. Failures are
+// logged only; stale entries expire with the cache TTL.
+func (u *CarUsecase) invalidate(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := u.cache.Delete(ctx, key); err != nil {
+			logger.FromContext(ctx).Warn("car cache invalidation failed", slog.String("key", key), logger.Err(err))
+		}
+	}
+	if err := u.cache.DeleteByPattern(ctx, "cars:list:*"); err != nil {
+		logger.FromContext(ctx).Warn("car list cache invalidation failed", logger.Err(err))
+	}
+}
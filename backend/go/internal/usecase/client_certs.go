@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"crypto/x509"
+	"slices"
+
+	"github.com/gino/cars-crud/internal/domain"
+)
+
+// ClientCerts maps verified client certificates onto principals so internal
+// services can authenticate with mTLS instead of API keys.
+type ClientCerts struct {
+	scopes map[string][]string
+}
+
+// NewClientCerts accepts certificates whose subject common name is a key of
+// scopes and grants them the listed scopes. Other certificates map to no
+// principal.
+func NewClientCerts(scopes map[string][]string) *ClientCerts {
+	return &ClientCerts{scopes: scopes}
+}
+
+func (c *ClientCerts) Principal(cert *x509.Certificate) (*domain.Principal, bool) {
+	name := cert.Subject.CommonName
+	scopes, ok := c.scopes[name]
+	if !ok || name == "" {
+		return nil, false
+	}
+	return &domain.Principal{
+		Subject: domain.CertSubjectPrefix + name,
+		Name:    name,
+		Scopes:  slices.Compact(slices.Sorted(slices.Values(scopes))),
+	}, true
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/pkg/jwtkeys"
+)
+
+var externalSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// ExternalIssuers verifies access tokens from trusted OpenID Connect
+// providers and maps their claims onto local scopes.
+type ExternalIssuers struct {
+	issuers     map[string]*jwtkeys.Remote
+	audience    string
+	leeway      time.Duration
+	scopeClaims []string
+	scopeMap    map[string][]string
+}
+
+// NewExternalIssuers trusts tokens from issuers that are addressed to
+// audience. The values of scopeClaims (space-delimited strings or arrays,
+// e.g. "scope", "roles" or "groups") become local scopes through scopeMap
+// only; a value is never taken as a local scope just because it has the same
+// name, so the provider cannot grant "admin" by naming a group after it.
+func NewExternalIssuers(
+	issuers []string,
+	audience string,
+	leeway time.Duration,
+	cacheTTL time.Duration,
+	scopeClaims []string,
+	scopeMap map[string][]string,
+) *ExternalIssuers {
+	e := &ExternalIssuers{
+		issuers:     make(map[string]*jwtkeys.Remote, len(issuers)),
+		audience:    audience,
+		leeway:      leeway,
+		scopeClaims: scopeClaims,
+		scopeMap:    scopeMap,
+	}
+	for _, iss := range issuers {
+		remote := jwtkeys.NewRemote(iss, cacheTTL)
+		e.issuers[remote.Issuer()] = remote
+	}
+	return e
+}
+
+func (e *ExternalIssuers) Trusts(iss string) bool {
+	_, ok := e.issuers[iss]
+	return ok
+}
+
+// Verify checks a token from a trusted issuer and returns its principal with
+// the mapped local scopes.
+func (e *ExternalIssuers) Verify(raw, iss string) (*domain.Principal, error) {
+	remote, ok := e.issuers[iss]
+	if !ok {
+		return nil, domain.ErrInvalidToken
+	}
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(raw, claims, remote.KeyFunc,
+		jwt.WithIssuer(iss),
+		jwt.WithAudience(e.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(e.leeway),
+		jwt.WithValidMethods(externalSigningMethods),
+	)
+	if errors.Is(err, jwtkeys.ErrKeysUnavailable) {
+		return nil, err
+	}
+	if err != nil || !token.Valid {
+		return nil, domain.ErrInvalidToken
+	}
+
+	p := &domain.Principal{Issuer: iss, Scopes: e.scopes(claims)}
+	p.Subject, _ = claims.GetSubject()
+	for _, name := range []string{"name", "preferred_username", "email"} {
+		if v, ok := claims[name].(string); ok && v != "" {
+			p.Name = v
+			break
+		}
+	}
+	return p, nil
+}
+
+func (e *ExternalIssuers) scopes(claims jwt.MapClaims) []string {
+	var scopes []string
+	for _, name := range e.scopeClaims {
+		for _, v := range claimValues(claims[name]) {
+			scopes = append(scopes, e.scopeMap[v]...)
+		}
+	}
+	slices.Sort(scopes)
+	return slices.Compact(scopes)
+}
+
+// claimValues reads a claim that is either a space-delimited string or an
+// array of strings.
+func claimValues(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return strings.Fields(val)
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/gino/cars-crud/pkg/jwtkeys"
+)
+
+// newTestIssuer serves discovery and a JWKS the way a provider whose issuer
+// is srv.URL+suffix would, and returns that issuer and its keys.
+func newTestIssuer(t *testing.T, suffix string) (string, *jwtkeys.Set) {
+	t.Helper()
+
+	keys, err := jwtkeys.Generate("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var issuer string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer":   issuer,
+				"jwks_uri": strings.TrimSuffix(issuer, "/") + "/jwks.json",
+			})
+		case "/jwks.json":
+			json.NewEncoder(w).Encode(keys.JWKS())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	issuer = srv.URL + suffix
+	return issuer, keys
+}
+
+func TestExternalIssuersVerify(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		suffix string
+	}{
+		{"issuer without trailing slash", ""},
+		{"issuer with trailing slash", "/"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			issuer, keys := newTestIssuer(t, tc.suffix)
+			external := NewExternalIssuers([]string{issuer}, "cars-api", 0, time.Minute,
+				[]string{"scope"}, map[string][]string{"fleet": {"cars:read"}})
+
+			token, err := keys.Sign(jwt.MapClaims{
+				"iss":   issuer,
+				"sub":   "alice",
+				"aud":   "cars-api",
+				"scope": "fleet admin",
+				"iat":   time.Now().Unix(),
+				"exp":   time.Now().Add(time.Minute).Unix(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !external.Trusts(issuer) {
+				t.Fatalf("Trusts(%q) = false, want true", issuer)
+			}
+			p, err := external.Verify(token, issuer)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if p.Subject != "alice" || p.Issuer != issuer {
+				t.Errorf("principal = %+v, want subject alice and issuer %q", p, issuer)
+			}
+			if len(p.Scopes) != 1 || p.Scopes[0] != "cars:read" {
+				t.Errorf("scopes = %v, want [cars:read]", p.Scopes)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+	"github.com/gino/cars-crud/pkg/logger"
+)
+
+// SecurityEventPublisher sends security events down the log pipeline.
+type SecurityEventPublisher interface {
+	PublishSecurityEvent(ctx context.Context, e domain.SecurityEvent) error
+}
+
+// LockoutUsecase guards API key validation and password login against brute
+// force. Failures are counted per client IP and per key prefix or username,
+// so guessing the secret of one key or the password of one user is stopped
+// even when spread over many addresses.
+type LockoutUsecase struct {
+	store  repository.LockoutStore
+	events SecurityEventPublisher
+	policy domain.LockoutPolicy
+}
+
+func NewLockoutUsecase(store repository.LockoutStore, events SecurityEventPublisher, policy domain.LockoutPolicy) *LockoutUsecase {
+	return &LockoutUsecase{store: store, events: events, policy: policy}
+}
+
+// Check returns the longest active lockout that applies to the attempt, or
+// nil when it may proceed.
+func (u *LockoutUsecase) Check(ctx context.Context, a domain.AuthAttempt) (*domain.Lockout, error) {
+	if u.policy.MaxFailures <= 0 {
+		return nil, nil
+	}
+
+	var longest *domain.Lockout
+	for _, subject := range lockoutSubjects(a) {
+		lockout, err := u.store.Locked(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		if lockout != nil && (longest == nil || lockout.ExpiresAt.After(longest.ExpiresAt)) {
+			longest = lockout
+		}
+	}
+	return longest, nil
+}
+
+// Fail records a failed attempt, locking out its subjects once they reach
+// the limit, and publishes the matching security events. Errors are logged
+// rather than returned so the caller's response does not depend on them.
+func (u *LockoutUsecase) Fail(ctx context.Context, a domain.AuthAttempt, reason string) {
+	if u.policy.MaxFailures <= 0 {
+		return
+	}
+
+	l := logger.FromContext(ctx)
+	prefix, _ := apiKeyPrefix(a.APIKey)
+	event := domain.SecurityEvent{
+		Type:      domain.SecurityEventAuthFailure,
+		IP:        a.IP,
+		KeyPrefix: prefix,
+		Username:  domain.NormalizeUsername(a.Username),
+		UserAgent: a.UserAgent,
+		RequestID: a.RequestID,
+		Reason:    reason,
+		Timestamp: time.Now(),
+	}
+
+	var lockouts []*domain.Lockout
+	for _, subject := range lockoutSubjects(a) {
+		failures, lockout, err := u.store.Fail(ctx, subject, u.policy)
+		if err != nil {
+			l.Error("failed to record auth failure", slog.String("subject", subject), logger.Err(err))
+			continue
+		}
+		event.Failures = max(event.Failures, failures)
+		if lockout != nil {
+			lockouts = append(lockouts, lockout)
+		}
+	}
+	u.publish(ctx, event)
+
+	for _, lockout := range lockouts {
+		lockedFor := time.Until(lockout.ExpiresAt).Round(time.Second)
+		l.Warn("locked out after repeated auth failures",
+			slog.String("subject", lockout.Subject),
+			slog.Int("level", lockout.Level),
+			slog.Duration("locked_for", lockedFor),
+		)
+
+		lockoutEvent := event
+		lockoutEvent.Type = domain.SecurityEventLockout
+		lockoutEvent.Subject = lockout.Subject
+		lockoutEvent.Level = lockout.Level
+		lockoutEvent.LockedFor = int64(lockedFor / time.Second)
+		u.publish(ctx, lockoutEvent)
+	}
+}
+
+// Succeed clears the failures counted for the attempt's subjects. Lockout
+// levels are kept, so a client that keeps failing still backs off further.
+func (u *LockoutUsecase) Succeed(ctx context.Context, a domain.AuthAttempt) {
+	if u.policy.MaxFailures <= 0 {
+		return
+	}
+	for _, subject := range lockoutSubjects(a) {
+		if err := u.store.Reset(ctx, subject); err != nil {
+			logger.FromContext(ctx).Error("failed to reset auth failures", slog.String("subject", subject), logger.Err(err))
+		}
+	}
+}
+
+func (u *LockoutUsecase) List(ctx context.Context) ([]domain.Lockout, error) {
+	return u.store.List(ctx)
+}
+
+func (u *LockoutUsecase) Unlock(ctx context.Context, subject string) error {
+	return u.store.Unlock(ctx, subject)
+}
+
+func (u *LockoutUsecase) publish(ctx context.Context, e domain.SecurityEvent) {
+	if err := u.events.PublishSecurityEvent(ctx, e); err != nil {
+		logger.FromContext(ctx).Warn("failed to publish security event", slog.String("type", e.Type), logger.Err(err))
+	}
+}
+
+// lockoutSubjects returns the subjects an attempt is counted against: its
+// IP and either the username or, for managed keys, the public key prefix.
+// Keys without a prefix, which can only be the bootstrap key, share the
+// fixed "key:bootstrap" subject so guessing it is limited across IPs too.
+// The secret part of the key is never stored.
+func lockoutSubjects(a domain.AuthAttempt) []string {
+	subjects := []string{"ip:" + a.IP}
+	if prefix, ok := apiKeyPrefix(a.APIKey); ok {
+		subjects = append(subjects, "key:"+prefix)
+	} else if a.APIKey != "" {
+		subjects = append(subjects, "key:"+domain.BootstrapKeyName)
+	}
+	if username := domain.NormalizeUsername(a.Username); username != "" {
+		subjects = append(subjects, "login:"+username)
+	}
+	return subjects
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+	"github.com/gino/cars-crud/pkg/logger"
+)
+
+const oneDay = 24 * time.Hour
+
+// LogArchiver rolls request logs into one gzipped NDJSON file per UTC day once
+// the whole day is older than the archive age, before the TTL index expires
+// them.
+type LogArchiver struct {
+	repo      repository.LogRepository
+	dir       string
+	after     time.Duration
+	retention time.Duration
+}
+
+func NewLogArchiver(repo repository.LogRepository, dir string, after, retention time.Duration) *LogArchiver {
+	return &LogArchiver{repo: repo, dir: dir, after: after, retention: retention}
+}
+
+func (a *LogArchiver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(max(interval, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		if err := a.Archive(ctx); err != nil && ctx.Err() == nil {
+			logger.FromContext(ctx).Error("failed to archive request logs", logger.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Archive writes every fully eligible day that has no archive file yet, and
+// rewrites a day's file when logs for that day were stored after it was
+// written, such as requests replayed late from the producer spool. Days that
+// may already have been partially expired by the TTL index are skipped.
+func (a *LogArchiver) Archive(ctx context.Context) error {
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	cutoff := now.Add(-a.after)
+	first := now.Add(-a.retention).Truncate(oneDay).Add(oneDay)
+
+	for start := first; !start.Add(oneDay).After(cutoff); start = start.Add(oneDay) {
+		path := filepath.Join(a.dir, fmt.Sprintf("request_logs-%s.ndjson.gz", start.Format("2006-01-02")))
+		if info, err := os.Stat(path); err == nil {
+			end := start.Add(oneDay)
+			late, err := a.repo.StoredSince(ctx, domain.LogFilter{From: &start, To: &end}, info.ModTime())
+			if err != nil {
+				return fmt.Errorf("check %s: %w", start.Format("2006-01-02"), err)
+			}
+			if !late {
+				continue
+			}
+		}
+
+		if err := a.archiveDay(ctx, start, path); err != nil {
+			return fmt.Errorf("archive %s: %w", start.Format("2006-01-02"), err)
+		}
+	}
+	return nil
+}
+
+// archiveDay writes the day to path, then sets the file's modification time
+// to when the read began, so logs stored while it ran count as late.
+func (a *LogArchiver) archiveDay(ctx context.Context, start time.Time, path string) error {
+	began := time.Now()
+	tmp, err := os.CreateTemp(a.dir, ".archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	enc := json.NewEncoder(gz)
+
+	end := start.Add(oneDay)
+	count := 0
+	err = a.repo.ForEach(ctx, domain.LogFilter{From: &start, To: &end}, func(entry domain.RequestLog) error {
+		count++
+		return enc.Encode(entry)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	if err := os.Chtimes(path, began, began); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("archived request logs",
+		slog.Int("count", count),
+		slog.String("file", path),
+	)
+	return nil
+}
@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+	"github.com/gino/cars-crud/pkg/jwtkeys"
+	"github.com/gino/cars-crud/pkg/logger"
+)
+
+const refreshTokenScheme = "rt_"
+
+// PrincipalResolver looks up the current state of a token subject when a
+// refresh token is used, so revoked credentials stop refreshing and scope
+// changes take effect.
+type PrincipalResolver interface {
+	Resolve(ctx context.Context, subject string) (*domain.Principal, error)
+}
+
+// TokenOptions configures the tokens TokenUsecase issues and accepts.
+// Leeway is the clock skew tolerated when checking exp, nbf and iat. An
+// empty Audience leaves out the "aud" claim.
+type TokenOptions struct {
+	Issuer     string
+	Audience   string
+	Leeway     time.Duration
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// TokenUsecase issues short-lived access tokens with rotating refresh tokens
+// and tracks revocations. Every access token carries a "jti" and the "sid"
+// of its refresh session, so either can be revoked.
+type TokenUsecase struct {
+	store    repository.TokenStore
+	resolver PrincipalResolver
+	keys     *jwtkeys.Set
+	external *ExternalIssuers
+	opts     TokenOptions
+}
+
+func NewTokenUsecase(
+	store repository.TokenStore,
+	resolver PrincipalResolver,
+	keys *jwtkeys.Set,
+	external *ExternalIssuers,
+	opts TokenOptions,
+) *TokenUsecase {
+	return &TokenUsecase{
+		store:    store,
+		resolver: resolver,
+		keys:     keys,
+		external: external,
+		opts:     opts,
+	}
+}
+
+// Issue starts a new session for p.
+func (u *TokenUsecase) Issue(ctx context.Context, p domain.Principal) (*domain.ValidateResponse, error) {
+	return u.issue(ctx, p, uuid.NewString())
+}
+
+// Refresh rotates a refresh token: the presented token is consumed and a new
+// pair is issued in the same session. Presenting a consumed token again
+// revokes the session.
+func (u *TokenUsecase) Refresh(ctx context.Context, refreshToken string) (*domain.ValidateResponse, error) {
+	if !strings.HasPrefix(refreshToken, refreshTokenScheme) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	session, err := u.store.ConsumeRefresh(ctx, hashToken(refreshToken))
+	if errors.Is(err, domain.ErrRefreshTokenReused) {
+		logger.FromContext(ctx).Warn("refresh token reused, revoking session",
+			slog.String("subject", session.Subject),
+			slog.String("session", session.Session),
+		)
+		if err := u.store.RevokeSession(ctx, session.Session, u.opts.RefreshTTL); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := u.store.Revoked(ctx, "", session.Session)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	p, err := u.resolver.Resolve(ctx, session.Subject)
+	if err != nil {
+		return nil, err
+	}
+	return u.issue(ctx, *p, session.Session)
+}
+
+// Revoke revokes a refresh token together with its session, or a single
+// access token. Unknown, invalid and expired tokens are ignored, as in
+// RFC 7009.
+func (u *TokenUsecase) Revoke(ctx context.Context, token string) error {
+	if strings.HasPrefix(token, refreshTokenScheme) {
+		session, err := u.store.ConsumeRefresh(ctx, hashToken(token))
+		if errors.Is(err, domain.ErrInvalidRefreshToken) {
+			return nil
+		}
+		if err != nil && !errors.Is(err, domain.ErrRefreshTokenReused) {
+			return err
+		}
+		return u.store.RevokeSession(ctx, session.Session, u.opts.RefreshTTL)
+	}
+
+	claims := jwt.MapClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, u.keys.KeyFunc, jwt.WithoutClaimsValidation())
+	if err != nil || !parsed.Valid {
+		return nil
+	}
+
+	jti, _ := claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if jti == "" || err != nil || exp == nil {
+		return nil
+	}
+	if ttl := time.Until(exp.Time); ttl > 0 {
+		return u.store.RevokeToken(ctx, jti, ttl)
+	}
+	return nil
+}
+
+// Verify checks an access token and returns the principal it was issued
+// for. Tokens are routed by issuer: our own are checked against the local
+// keys and the revocation list, others must come from a trusted external
+// issuer. It fails with domain.ErrInvalidToken or domain.ErrTokenRevoked;
+// other errors mean the token could not be checked.
+func (u *TokenUsecase) Verify(ctx context.Context, raw string) (*domain.Principal, error) {
+	unverified := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(raw, unverified); err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	if iss, _ := unverified.GetIssuer(); iss != u.opts.Issuer {
+		if u.external == nil || !u.external.Trusts(iss) {
+			return nil, domain.ErrInvalidToken
+		}
+		return u.external.Verify(raw, iss)
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithIssuer(u.opts.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(u.opts.Leeway),
+	}
+	if u.opts.Audience != "" {
+		options = append(options, jwt.WithAudience(u.opts.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(raw, claims, u.keys.KeyFunc, options...)
+	if err != nil || !token.Valid {
+		return nil, domain.ErrInvalidToken
+	}
+
+	jti, _ := claims["jti"].(string)
+	sid, _ := claims["sid"].(string)
+	if jti == "" {
+		return nil, domain.ErrInvalidToken
+	}
+	revoked, err := u.store.Revoked(ctx, jti, sid)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, domain.ErrTokenRevoked
+	}
+	return principalFromClaims(claims), nil
+}
+
+// JWKS returns the public keys that verify issued tokens.
+func (u *TokenUsecase) JWKS() jwtkeys.JWKS {
+	return u.keys.JWKS()
+}
+
+func (u *TokenUsecase) issue(ctx context.Context, p domain.Principal, session string) (*domain.ValidateResponse, error) {
+	now := time.Now()
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken := refreshTokenScheme + secret
+
+	err = u.store.SaveRefresh(ctx, hashToken(refreshToken), domain.RefreshSession{
+		Session:   session,
+		Subject:   p.Subject,
+		ExpiresAt: now.Add(u.opts.RefreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   u.opts.Issuer,
+		"sub":   p.Subject,
+		"name":  p.Name,
+		"scope": strings.Join(p.Scopes, " "),
+		"jti":   uuid.NewString(),
+		"sid":   session,
+		"iat":   now.Unix(),
+		"nbf":   now.Unix(),
+		"exp":   now.Add(u.opts.AccessTTL).Unix(),
+	}
+	if u.opts.Audience != "" {
+		claims["aud"] = u.opts.Audience
+	}
+	if len(p.Roles) > 0 {
+		claims["roles"] = p.Roles
+	}
+	signed, err := u.keys.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ValidateResponse{
+		Token:        signed,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(u.opts.AccessTTL / time.Second),
+	}, nil
+}
+
+// principalFromClaims reads the principal of a verified token.
+func principalFromClaims(claims jwt.MapClaims) *domain.Principal {
+	p := &domain.Principal{}
+	p.Subject, _ = claims.GetSubject()
+	p.Issuer, _ = claims.GetIssuer()
+	p.Name, _ = claims["name"].(string)
+	scope, _ := claims["scope"].(string)
+	p.Scopes = strings.Fields(scope)
+	p.Roles = claimValues(claims["roles"])
+	return p
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
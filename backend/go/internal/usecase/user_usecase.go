@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/gino/cars-crud/internal/domain"
+	"github.com/gino/cars-crud/internal/repository"
+	"github.com/gino/cars-crud/pkg/logger"
+)
+
+const passwordCost = 12
+
+// dummyHash is checked against when a username is unknown, so a failed
+// login takes as long whether or not the user exists.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), passwordCost)
+	return hash
+})
+
+// UserUsecase manages user accounts and verifies their passwords.
+type UserUsecase struct {
+	repo repository.UserRepository
+}
+
+func NewUserUsecase(repo repository.UserRepository) *UserUsecase {
+	return &UserUsecase{repo: repo}
+}
+
+func (u *UserUsecase) Create(ctx context.Context, req domain.CreateUserRequest) (*domain.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), passwordCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := domain.User{
+		Username:     domain.NormalizeUsername(req.Username),
+		Name:         req.Name,
+		PasswordHash: string(hash),
+		Roles:        req.Roles,
+	}
+	if err := u.repo.Create(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (u *UserUsecase) GetAll(ctx context.Context, offset, limit int) ([]domain.User, int64, error) {
+	return u.repo.GetAll(ctx, offset, limit)
+}
+
+func (u *UserUsecase) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return u.repo.GetByID(ctx, id)
+}
+
+// Update applies the fields set in req. Disabling a user or changing their
+// roles takes effect on their next token refresh.
+func (u *UserUsecase) Update(ctx context.Context, id uuid.UUID, req domain.UpdateUserRequest) (*domain.User, error) {
+	user, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	if req.Roles != nil {
+		user.Roles = *req.Roles
+	}
+	if req.Disabled != nil {
+		user.Disabled = *req.Disabled
+	}
+	if req.Password != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), passwordCost)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = string(hash)
+	}
+
+	if err := u.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *UserUsecase) Delete(ctx context.Context, id uuid.UUID) error {
+	return u.repo.Delete(ctx, id)
+}
+
+// Authenticate returns the user matching the credentials, or
+// domain.ErrInvalidCredentials when the username is unknown, the password is
+// wrong or the user is disabled.
+func (u *UserUsecase) Authenticate(ctx context.Context, username, password string) (*domain.User, error) {
+	user, err := u.repo.GetByUsername(ctx, domain.NormalizeUsername(username))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil || user.Disabled {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	if err := u.repo.TouchLastLogin(ctx, user.ID, now); err != nil {
+		logger.FromContext(ctx).Warn("failed to record user login", slog.String("user_id", user.ID.String()), logger.Err(err))
+	} else {
+		user.LastLoginAt = &now
+	}
+	return user, nil
+}
+
+// Resolve returns the current principal of a "user:<id>" subject, failing
+// with domain.ErrInvalidCredentials once the user is deleted or disabled.
+func (u *UserUsecase) Resolve(ctx context.Context, subject string) (*domain.Principal, error) {
+	id, err := uuid.Parse(strings.TrimPrefix(subject, domain.UserSubjectPrefix))
+	if err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+	user, err := u.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	p := user.Principal()
+	return &p, nil
+}
+
+// SubjectResolver resolves token subjects of users through users and every
+// other subject through keys.
+type SubjectResolver struct {
+	keys  PrincipalResolver
+	users PrincipalResolver
+}
+
+func NewSubjectResolver(keys, users PrincipalResolver) *SubjectResolver {
+	return &SubjectResolver{keys: keys, users: users}
+}
+
+func (r *SubjectResolver) Resolve(ctx context.Context, subject string) (*domain.Principal, error) {
+	if strings.HasPrefix(subject, domain.UserSubjectPrefix) {
+		return r.users.Resolve(ctx, subject)
+	}
+	return r.keys.Resolve(ctx, subject)
+}
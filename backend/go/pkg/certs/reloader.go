@@ -0,0 +1,182 @@
+// Package certs serves a TLS certificate and an optional client CA bundle
+// from files, reloading them when the files change so certificates can be
+// rotated without a restart.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gino/cars-crud/pkg/logger"
+)
+
+// Reloader holds the current certificate and client CA pool. Both are
+// swapped atomically on reload; handshakes already in progress keep the
+// previous ones.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu     sync.RWMutex
+	cert   *tls.Certificate
+	pool   *x509.CertPool
+	stamps []time.Time
+}
+
+// New loads the certificate pair and, when caFile is not empty, the PEM
+// bundle of CAs that client certificates are verified against.
+func New(certFile, keyFile, caFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	stamps, err := r.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(stamps); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ServerConfig returns a TLS config serving the current certificate. With a
+// client CA bundle, client certificates are requested and verified against
+// it according to clientAuth.
+func (r *Reloader) ServerConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	base := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+		GetCertificate: r.certificate,
+	}
+	if r.caFile == "" {
+		return base
+	}
+
+	cfg := base.Clone()
+	cfg.ClientAuth = clientAuth
+	cfg.ClientCAs = r.clientCAs()
+	// Each handshake gets a copy of the config with the CA pool as of now,
+	// so a reloaded bundle applies to new connections.
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c := base.Clone()
+		c.ClientAuth = clientAuth
+		c.ClientCAs = r.clientCAs()
+		return c, nil
+	}
+	return cfg
+}
+
+// Watch checks the files' modification times every interval until ctx is
+// done and reloads them when any has changed. A failed reload is logged and
+// the previous certificate stays in use until the files change again.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	l := logger.FromContext(ctx).With(slog.String("component", "tls_reloader"))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stamps, err := r.modTimes()
+		if err != nil {
+			l.Warn("failed to check tls files", logger.Err(err))
+			continue
+		}
+		if !r.changed(stamps) {
+			continue
+		}
+		if err := r.load(stamps); err != nil {
+			r.mu.Lock()
+			r.stamps = stamps
+			r.mu.Unlock()
+			l.Error("failed to reload tls files, keeping the previous certificate", logger.Err(err))
+			continue
+		}
+		l.Info("reloaded tls files", slog.Time("not_after", r.leaf().NotAfter))
+	}
+}
+
+func (r *Reloader) load(stamps []time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate: %w", err)
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return fmt.Errorf("parse certificate: %w", err)
+		}
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		data, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("load client ca: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return errors.New("load client ca: no certificates found in " + r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert, r.pool, r.stamps = &cert, pool, stamps
+	return nil
+}
+
+func (r *Reloader) modTimes() ([]time.Time, error) {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+
+	stamps := make([]time.Time, len(files))
+	for i, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, err
+		}
+		stamps[i] = info.ModTime()
+	}
+	return stamps, nil
+}
+
+func (r *Reloader) changed(stamps []time.Time) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i, t := range stamps {
+		if !t.Equal(r.stamps[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) certificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *Reloader) clientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+func (r *Reloader) leaf() *x509.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert.Leaf
+}
@@ -2,49 +2,156 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	AppPort         string
-	PostgresHost    string
-	PostgresPort    string
-	PostgresUser    string
-	PostgresPass    string
-	PostgresDB      string
-	RedisAddr       string
-	RedisPassword   string
-	RedisDB         string
-	KafkaBrokers    string
-	KafkaTopic      string
-	MongoURI        string
-	MongoDB         string
-	MongoCollection string
-	JWTSecret       string
-	APIKey          string
+	AppPort                 string
+	TLSCertFile             string
+	TLSKeyFile              string
+	TLSClientCAFile         string
+	TLSClientAuth           string
+	TLSClientScopes         map[string][]string
+	TLSReloadInterval       time.Duration
+	PostgresHost            string
+	PostgresPort            string
+	PostgresUser            string
+	PostgresPass            string
+	PostgresDB              string
+	RedisAddr               string
+	RedisPassword           string
+	RedisDB                 string
+	KafkaBrokers            string
+	KafkaTopic              string
+	KafkaEncoding           string
+	KafkaProducerBufferSize int
+	KafkaProducerBatchSize  int
+	KafkaProducerDropPolicy string
+	KafkaSpoolDir           string
+	KafkaSpoolMaxBytes      int64
+	KafkaSpoolSegmentBytes  int64
+	KafkaSpoolDrainInterval time.Duration
+	KafkaConsumerMaxLag     int64
+	MongoURI                string
+	MongoDB                 string
+	MongoCollection         string
+	MongoSecurityCollection string
+	LogLevel                string
+	LogFormat               string
+	LogRetentionDays        int
+	LogArchiveDir           string
+	LogArchiveAfterDays     int
+	LogArchiveInterval      time.Duration
+	LogCaptureBodies        bool
+	LogBodyMaxBytes         int
+	LogRedactFields         []string
+	LogRedactHeaders        []string
+	LogExcludePaths         []string
+	LogSampleRate           float64
+	LogRouteSampleRates     map[string]float64
+	LogAlwaysLogErrors      bool
+	LogSlowThreshold        time.Duration
+	JWTSecret               string
+	JWTKeysDir              string
+	JWTSigningKeyID         string
+	JWTAccessTTL            time.Duration
+	JWTIssuer               string
+	JWTAudience             string
+	JWTLeeway               time.Duration
+	LockoutMaxFailures      int
+	LockoutWindow           time.Duration
+	LockoutDuration         time.Duration
+	LockoutMaxDuration      time.Duration
+	LockoutResetAfter       time.Duration
+	TrustedProxies          []string
+	RateLimitEnabled        bool
+	RateLimits              map[string]string
+	RateLimitKeys           map[string]string
+	OIDCIssuers             []string
+	OIDCAudience            string
+	OIDCScopeClaims         []string
+	OIDCScopeMap            map[string][]string
+	OIDCJWKSCacheTTL        time.Duration
+	JWTRefreshTTL           time.Duration
+	APIKey                  string
 }
 
 func Load() *Config {
 	_ = godotenv.Load()
 
 	return &Config{
-		AppPort:         getEnv("APP_PORT", "8080"),
-		PostgresHost:    getEnv("POSTGRES_HOST", "localhost"),
-		PostgresPort:    getEnv("POSTGRES_PORT", "5432"),
-		PostgresUser:    getEnv("POSTGRES_USER", "cars"),
-		PostgresPass:    getEnv("POSTGRES_PASSWORD", "cars"),
-		PostgresDB:      getEnv("POSTGRES_DB", "cars"),
-		RedisAddr:       getEnv("REDIS_ADDR", "localhost:6379"),
-		RedisPassword:   getEnv("REDIS_PASSWORD", ""),
-		RedisDB:         getEnv("REDIS_DB", "0"),
-		KafkaBrokers:    getEnv("KAFKA_BROKERS", "localhost:9092"),
-		KafkaTopic:      getEnv("KAFKA_TOPIC", "car-api-logs"),
-		MongoURI:        getEnv("MONGO_URI", "mongodb://localhost:27017"),
-		MongoDB:         getEnv("MONGO_DB", "cars_logs"),
-		MongoCollection: getEnv("MONGO_COLLECTION", "request_logs"),
-		JWTSecret:       getEnv("JWT_SECRET", "super-secret-change-me"),
-		APIKey:          getEnv("API_KEY", "my-api-key-12345"),
+		AppPort:                 getEnv("APP_PORT", "8080"),
+		TLSCertFile:             getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:              getEnv("TLS_KEY_FILE", ""),
+		TLSClientCAFile:         getEnv("TLS_CLIENT_CA_FILE", ""),
+		TLSClientAuth:           getEnv("TLS_CLIENT_AUTH", "optional"),
+		TLSClientScopes:         getEnvMultiMap("TLS_CLIENT_SCOPES"),
+		TLSReloadInterval:       getEnvDuration("TLS_RELOAD_INTERVAL", 30*time.Second),
+		PostgresHost:            getEnv("POSTGRES_HOST", "localhost"),
+		PostgresPort:            getEnv("POSTGRES_PORT", "5432"),
+		PostgresUser:            getEnv("POSTGRES_USER", "cars"),
+		PostgresPass:            getEnv("POSTGRES_PASSWORD", "cars"),
+		PostgresDB:              getEnv("POSTGRES_DB", "cars"),
+		RedisAddr:               getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:           getEnv("REDIS_PASSWORD", ""),
+		RedisDB:                 getEnv("REDIS_DB", "0"),
+		KafkaBrokers:            getEnv("KAFKA_BROKERS", "localhost:9092"),
+		KafkaTopic:              getEnv("KAFKA_TOPIC", "car-api-logs"),
+		KafkaEncoding:           getEnv("KAFKA_ENCODING", "json"),
+		KafkaProducerBufferSize: getEnvInt("KAFKA_PRODUCER_BUFFER_SIZE", 1000),
+		KafkaProducerBatchSize:  getEnvInt("KAFKA_PRODUCER_BATCH_SIZE", 100),
+		KafkaProducerDropPolicy: getEnv("KAFKA_PRODUCER_DROP_POLICY", "drop-oldest"),
+		KafkaSpoolDir:           getEnv("KAFKA_SPOOL_DIR", ""),
+		KafkaSpoolMaxBytes:      int64(getEnvInt("KAFKA_SPOOL_MAX_BYTES", 256<<20)),
+		KafkaSpoolSegmentBytes:  int64(getEnvInt("KAFKA_SPOOL_SEGMENT_BYTES", 16<<20)),
+		KafkaSpoolDrainInterval: getEnvDuration("KAFKA_SPOOL_DRAIN_INTERVAL", 5*time.Second),
+		KafkaConsumerMaxLag:     int64(getEnvInt("KAFKA_CONSUMER_MAX_LAG", 10000)),
+		MongoURI:                getEnv("MONGO_URI", "mongodb://localhost:27017"),
+		MongoDB:                 getEnv("MONGO_DB", "cars_logs"),
+		MongoCollection:         getEnv("MONGO_COLLECTION", "request_logs"),
+		MongoSecurityCollection: getEnv("MONGO_SECURITY_COLLECTION", "security_events"),
+		LogLevel:                getEnv("LOG_LEVEL", "info"),
+		LogFormat:               getEnv("LOG_FORMAT", "json"),
+		LogRetentionDays:        getEnvInt("LOG_RETENTION_DAYS", 0),
+		LogArchiveDir:           getEnv("LOG_ARCHIVE_DIR", ""),
+		LogArchiveAfterDays:     getEnvInt("LOG_ARCHIVE_AFTER_DAYS", 7),
+		LogArchiveInterval:      getEnvDuration("LOG_ARCHIVE_INTERVAL", time.Hour),
+		LogCaptureBodies:        getEnvBool("LOG_CAPTURE_BODIES", false),
+		LogBodyMaxBytes:         getEnvInt("LOG_BODY_MAX_BYTES", 4096),
+		LogRedactFields:         getEnvList("LOG_REDACT_FIELDS", "api_key,key,password,token,refresh_token,secret"),
+		LogRedactHeaders:        getEnvList("LOG_REDACT_HEADERS", "Authorization,Cookie,Set-Cookie,X-Api-Key"),
+		LogExcludePaths:         getEnvList("LOG_EXCLUDE_PATHS", "/health,/swagger/"),
+		LogSampleRate:           getEnvFloat("LOG_SAMPLE_RATE", 1),
+		LogRouteSampleRates:     getEnvFloatMap("LOG_ROUTE_SAMPLE_RATES"),
+		LogAlwaysLogErrors:      getEnvBool("LOG_ALWAYS_LOG_ERRORS", true),
+		LogSlowThreshold:        getEnvDuration("LOG_SLOW_THRESHOLD", time.Second),
+		JWTSecret:               getEnv("JWT_SECRET", "super-secret-change-me"),
+		JWTKeysDir:              getEnv("JWT_KEYS_DIR", ""),
+		JWTSigningKeyID:         getEnv("JWT_SIGNING_KEY_ID", ""),
+		JWTAccessTTL:            getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+		JWTIssuer:               getEnv("JWT_ISSUER", "cars-crud-api"),
+		JWTAudience:             getEnvOptional("JWT_AUDIENCE", "cars-crud-api"),
+		JWTLeeway:               getEnvDuration("JWT_LEEWAY", 30*time.Second),
+		LockoutMaxFailures:      getEnvInt("LOCKOUT_MAX_FAILURES", 5),
+		LockoutWindow:           getEnvDuration("LOCKOUT_WINDOW", 15*time.Minute),
+		LockoutDuration:         getEnvDuration("LOCKOUT_DURATION", time.Minute),
+		LockoutMaxDuration:      getEnvDuration("LOCKOUT_MAX_DURATION", time.Hour),
+		LockoutResetAfter:       getEnvDuration("LOCKOUT_RESET_AFTER", 24*time.Hour),
+		TrustedProxies:          getEnvList("TRUSTED_PROXIES", ""),
+		RateLimitEnabled:        getEnvBool("RATE_LIMIT_ENABLED", true),
+		RateLimits:              getEnvMap("RATE_LIMITS", "auth=10/1m,jwks=600/1m,cars=600/1m,logs=120/1m,admin=60/1m"),
+		RateLimitKeys:           getEnvMap("RATE_LIMIT_KEYS", ""),
+		OIDCIssuers:             getEnvList("OIDC_ISSUERS", ""),
+		OIDCAudience:            getEnv("OIDC_AUDIENCE", ""),
+		OIDCScopeClaims:         getEnvList("OIDC_SCOPE_CLAIMS", "scope,scp"),
+		OIDCScopeMap:            getEnvMultiMap("OIDC_SCOPE_MAP"),
+		OIDCJWKSCacheTTL:        getEnvDuration("OIDC_JWKS_CACHE_TTL", 10*time.Minute),
+		JWTRefreshTTL:           getEnvDuration("JWT_REFRESH_TTL", 7*24*time.Hour),
+		APIKey:                  getEnv("API_KEY", ""),
 	}
 }
 
@@ -54,3 +161,94 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvOptional is getEnv for settings where an empty value means "none":
+// the fallback only applies when key is not set at all.
+func getEnvOptional(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if v, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+// getEnvList splits a comma-separated list. Setting key to an empty value
+// gives an empty list rather than the fallback.
+func getEnvList(key, fallback string) []string {
+	var list []string
+	for _, v := range strings.Split(getEnvOptional(key, fallback), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return v
+	}
+	return fallback
+}
+
+// getEnvFloatMap parses "key=value" pairs separated by commas. Keys may
+// contain "=" (only the last one splits), entries that do not parse are
+// skipped.
+func getEnvFloatMap(key string) map[string]float64 {
+	m := make(map[string]float64)
+	for _, pair := range getEnvList(key, "") {
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			continue
+		}
+		if v, err := strconv.ParseFloat(strings.TrimSpace(pair[i+1:]), 64); err == nil {
+			m[strings.TrimSpace(pair[:i])] = v
+		}
+	}
+	return m
+}
+
+// getEnvMap parses "key=value" pairs separated by commas.
+func getEnvMap(key, fallback string) map[string]string {
+	m := make(map[string]string)
+	for _, pair := range getEnvList(key, fallback) {
+		k, v, ok := strings.Cut(pair, "=")
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); ok && k != "" && v != "" {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// getEnvMultiMap parses "key=value" pairs separated by commas into a map of
+// lists, so a key may be repeated to map to several values.
+func getEnvMultiMap(key string) map[string][]string {
+	m := make(map[string][]string)
+	for _, pair := range getEnvList(key, "") {
+		k, v, ok := strings.Cut(pair, "=")
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); ok && k != "" && v != "" {
+			m[k] = append(m[k], v)
+		}
+	}
+	return m
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if v, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,212 @@
+// Package jwtkeys holds the keys used to sign and verify JWTs: either a set
+// of asymmetric keys identified by "kid" and published as a JWKS, or a
+// single HMAC secret.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key is one named key. Private is nil for keys that only verify, such as
+// retired keys kept around until their tokens expire.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+type Set struct {
+	keys    map[string]*Key
+	signing *Key
+	secret  []byte
+}
+
+// NewHMAC returns a set that signs and verifies HS256 tokens with secret.
+func NewHMAC(secret string) *Set {
+	return &Set{secret: []byte(secret)}
+}
+
+// Generate returns a set holding a single new Ed25519 key, for tools and
+// tests that need signing keys without files.
+func Generate(kid string) (*Set, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key := &Key{ID: kid, Method: jwt.SigningMethodEdDSA, Private: priv, Public: pub}
+	return &Set{keys: map[string]*Key{kid: key}, signing: key}, nil
+}
+
+// LoadDir loads every "<kid>.pem" file in dir. Files may hold a PKCS#8 or
+// PKCS#1 private key (RSA or Ed25519) or a PKIX public key. Tokens are
+// signed with activeKID, or with the private key whose kid sorts last when
+// activeKID is empty, so date-based names rotate naturally.
+func LoadDir(dir, activeKID string) (*Set, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Set{keys: make(map[string]*Key, len(paths))}
+	var signable []string
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := loadKey(kid, path)
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", path, err)
+		}
+		s.keys[kid] = key
+		if key.Private != nil {
+			signable = append(signable, kid)
+		}
+	}
+
+	if len(signable) == 0 {
+		return nil, fmt.Errorf("no private keys in %s", dir)
+	}
+	if activeKID == "" {
+		activeKID = slices.Max(signable)
+	}
+	active, ok := s.keys[activeKID]
+	if !ok || active.Private == nil {
+		return nil, fmt.Errorf("%w: no private key %q in %s", ErrUnknownKey, activeKID, dir)
+	}
+	s.signing = active
+	return s, nil
+}
+
+func loadKey(kid, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// Sign signs claims with the active key, setting its kid in the header.
+func (s *Set) Sign(claims jwt.Claims) (string, error) {
+	if s.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	}
+
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	token.Header["kid"] = s.signing.ID
+	return token.SignedString(s.signing.Private)
+}
+
+// KeyFunc resolves the verification key for a token by its kid. The token
+// algorithm must match the key, so an HMAC token can never be checked
+// against a public key.
+func (s *Set) KeyFunc(t *jwt.Token) (interface{}, error) {
+	if s.keys == nil {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return s.secret, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	if !key.accepts(t.Method) {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.Public, nil
+}
+
+// JWK is a public key in RFC 7517 form.
+type JWK struct {
+	Kty string `json:"kty" example:"RSA"`
+	Kid string `json:"kid" example:"2024-05-01"`
+	Use string `json:"use" example:"sig"`
+	Alg string `json:"alg" example:"RS256"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, sorted by kid. It is empty for
+// HMAC sets, whose secret must never be published.
+func (s *Set) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, kid := range slices.Sorted(maps.Keys(s.keys)) {
+		key := s.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64(pub.N.Bytes())
+			jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = b64(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,274 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrKeysUnavailable means the issuer's keys could not be fetched, as opposed
+// to a token that does not match them.
+var ErrKeysUnavailable = errors.New("issuer keys unavailable")
+
+// refetchBackoff is the minimum time between fetches, so tokens with made-up
+// kids or an unreachable issuer cannot make us hammer it.
+const refetchBackoff = time.Minute
+
+// Remote verifies tokens against the JWKS of an OpenID Connect issuer. The
+// JWKS location comes from the issuer's discovery document; keys are cached
+// for the TTL and refetched early when a token names an unknown kid. Fetches
+// run without holding the lock, so verification against cached keys never
+// waits on the issuer, and concurrent refetches share one request.
+type Remote struct {
+	issuer string
+	ttl    time.Duration
+	client *http.Client
+	fetch  singleflight.Group
+
+	mu        sync.RWMutex
+	jwksURI   string
+	keys      map[string]*Key
+	fetchedAt time.Time
+	lastFetch time.Time
+}
+
+func NewRemote(issuer string, ttl time.Duration) *Remote {
+	return &Remote{
+		issuer: issuer,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (r *Remote) Issuer() string {
+	return r.issuer
+}
+
+func (r *Remote) KeyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, err := r.key(kid)
+	if err != nil {
+		return nil, err
+	}
+	if !key.accepts(t.Method) {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.Public, nil
+}
+
+func (r *Remote) key(kid string) (*Key, error) {
+	r.mu.RLock()
+	key, known := r.keys[kid]
+	due := (time.Since(r.fetchedAt) > r.ttl || !known) && r.fetchAllowed()
+	r.mu.RUnlock()
+
+	var fetchErr error
+	if due {
+		// On failure the cached keys, if any, stay in use.
+		result := r.fetch.DoChan("jwks", func() (interface{}, error) {
+			return nil, r.refresh()
+		})
+		// A known key only got stale: keep using it while the fetch runs.
+		if known {
+			return key, nil
+		}
+		fetchErr = (<-result).Err
+	}
+
+	r.mu.RLock()
+	key, known = r.keys[kid]
+	cached := r.keys != nil
+	r.mu.RUnlock()
+
+	if !cached {
+		if fetchErr == nil {
+			fetchErr = errors.New("waiting to retry")
+		}
+		return nil, fmt.Errorf("%w: %s: %v", ErrKeysUnavailable, r.issuer, fetchErr)
+	}
+	if !known {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// fetchAllowed reports whether the backoff since the last fetch has passed.
+// The caller must hold r.mu.
+func (r *Remote) fetchAllowed() bool {
+	return r.lastFetch.IsZero() || time.Since(r.lastFetch) > refetchBackoff
+}
+
+func (r *Remote) refresh() error {
+	r.mu.Lock()
+	// A fetch that finished while this caller waited already covers it.
+	if !r.fetchAllowed() {
+		r.mu.Unlock()
+		return nil
+	}
+	r.lastFetch = time.Now()
+	jwksURI := r.jwksURI
+	r.mu.Unlock()
+
+	if jwksURI == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		// The issuer is kept exactly as configured, since tokens must match it;
+		// only the discovery URL drops a trailing slash (e.g. Auth0's issuers).
+		url := strings.TrimSuffix(r.issuer, "/") + "/.well-known/openid-configuration"
+		if err := r.getJSON(url, &discovery); err != nil {
+			return err
+		}
+		if discovery.Issuer != r.issuer || discovery.JWKSURI == "" {
+			return fmt.Errorf("discovery document does not match issuer %s", r.issuer)
+		}
+		jwksURI = discovery.JWKSURI
+	}
+
+	var set JWKS
+	if err := r.getJSON(jwksURI, &set); err != nil {
+		return err
+	}
+	keys, err := ParseJWKS(set)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jwksURI, r.keys, r.fetchedAt = jwksURI, keys, time.Now()
+	return nil
+}
+
+func (r *Remote) getJSON(url string, v interface{}) error {
+	resp, err := r.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// ParseJWKS converts the signing keys of a JWKS, skipping keys of unknown
+// types and keys meant for encryption.
+func ParseJWKS(set JWKS) (map[string]*Key, error) {
+	keys := make(map[string]*Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		pub, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.Kid, err)
+		}
+		if pub == nil {
+			continue
+		}
+
+		key := &Key{ID: jwk.Kid, Public: pub}
+		if jwk.Alg != "" {
+			if key.Method = jwt.GetSigningMethod(jwk.Alg); key.Method == nil {
+				continue
+			}
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (j JWK) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := b64Int(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64Int(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := b64Int(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64Int(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+// accepts reports whether a token signed with m may be verified by the key.
+// Keys published without "alg" accept any algorithm of their key type.
+func (k *Key) accepts(m jwt.SigningMethod) bool {
+	if k.Method != nil {
+		return m.Alg() == k.Method.Alg()
+	}
+	switch k.Public.(type) {
+	case *rsa.PublicKey:
+		switch m.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return true
+		}
+	case *ecdsa.PublicKey:
+		_, ok := m.(*jwt.SigningMethodECDSA)
+		return ok
+	case ed25519.PublicKey:
+		_, ok := m.(*jwt.SigningMethodEd25519)
+		return ok
+	}
+	return false
+}
+
+func b64Int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -1,10 +1,30 @@
 import apiClient from "./client";
 import type { SuccessResponse, ValidateResponse } from "../types/car";
 
-export async function validateApiKey(apiKey: string): Promise<string> {
+export async function validateApiKey(apiKey: string): Promise<ValidateResponse> {
     const response = await apiClient.post<SuccessResponse<ValidateResponse>>(
         "/auth/validate",
         { api_key: apiKey }
     );
-    return response.data.data.token;
+    return response.data.data;
+}
+
+export async function loginWithPassword(username: string, password: string): Promise<ValidateResponse> {
+    const response = await apiClient.post<SuccessResponse<ValidateResponse>>(
+        "/auth/login",
+        { username, password }
+    );
+    return response.data.data;
+}
+
+export async function refreshSession(refreshToken: string): Promise<ValidateResponse> {
+    const response = await apiClient.post<SuccessResponse<ValidateResponse>>(
+        "/auth/refresh",
+        { refresh_token: refreshToken }
+    );
+    return response.data.data;
+}
+
+export async function revokeToken(token: string): Promise<void> {
+    await apiClient.post("/auth/revoke", { token });
 }
@@ -1,4 +1,4 @@
-import axios from "axios";
+import axios, { type InternalAxiosRequestConfig } from "axios";
 
 const apiClient = axios.create({
     baseURL: "",
@@ -8,13 +8,17 @@ const apiClient = axios.create({
 });
 
 let getTokenFn: (() => string | null) | null = null;
+let refreshFn: (() => Promise<string | null>) | null = null;
 let onUnauthorizedFn: (() => void) | null = null;
+let pendingRefresh: Promise<string | null> | null = null;
 
 export function setAuthInterceptors(
     getToken: () => string | null,
+    refresh: () => Promise<string | null>,
     onUnauthorized: () => void
 ) {
     getTokenFn = getToken;
+    refreshFn = refresh;
     onUnauthorizedFn = onUnauthorized;
 }
 
@@ -28,10 +32,39 @@ apiClient.interceptors.request.use((config) => {
     return config;
 });
 
+// Concurrent 401s share a single refresh, since each refresh token can only
+// be used once.
+function refreshOnce(): Promise<string | null> {
+    if (!pendingRefresh && refreshFn) {
+        pendingRefresh = refreshFn().finally(() => {
+            pendingRefresh = null;
+        });
+    }
+    return pendingRefresh ?? Promise.resolve(null);
+}
+
 apiClient.interceptors.response.use(
     (response) => response,
-    (error) => {
-        if (error.response?.status === 401 && onUnauthorizedFn) {
+    async (error) => {
+        const config = error.config as
+            | (InternalAxiosRequestConfig & { _retried?: boolean })
+            | undefined;
+
+        // Auth endpoints report their own failures.
+        if (error.response?.status !== 401 || !config || config.url?.startsWith("/auth/")) {
+            return Promise.reject(error);
+        }
+
+        if (!config._retried) {
+            config._retried = true;
+            const token = await refreshOnce();
+            if (token) {
+                config.headers.Authorization = `Bearer ${token}`;
+                return apiClient(config);
+            }
+        }
+
+        if (onUnauthorizedFn) {
             onUnauthorizedFn();
         }
         return Promise.reject(error);
@@ -4,39 +4,70 @@ import {
     useState,
     useEffect,
     useCallback,
+    useRef,
     type ReactNode,
 } from "react";
 import { setAuthInterceptors } from "../api/client";
+import { refreshSession, revokeToken } from "../api/auth";
+import type { ValidateResponse } from "../types/car";
 
 interface AuthContextType {
     token: string | null;
     isAuthenticated: boolean;
-    login: (token: string) => void;
+    login: (session: ValidateResponse) => void;
     logout: () => void;
 }
 
 const AuthContext = createContext<AuthContextType | null>(null);
 
 export function AuthProvider({ children }: { children: ReactNode }) {
-    const [token, setToken] = useState<string | null>(null);
+    const [session, setSession] = useState<ValidateResponse | null>(null);
+    // The interceptors read the latest session between renders.
+    const sessionRef = useRef<ValidateResponse | null>(null);
 
-    const logout = useCallback(() => {
-        setToken(null);
+    const update = useCallback((next: ValidateResponse | null) => {
+        sessionRef.current = next;
+        setSession(next);
     }, []);
 
-    const login = useCallback((newToken: string) => {
-        setToken(newToken);
-    }, []);
+    const logout = useCallback(() => {
+        const current = sessionRef.current;
+        update(null);
+        if (current) {
+            revokeToken(current.refresh_token).catch(() => {});
+        }
+    }, [update]);
+
+    const login = useCallback(
+        (next: ValidateResponse) => {
+            update(next);
+        },
+        [update]
+    );
+
+    const refresh = useCallback(async () => {
+        const current = sessionRef.current;
+        if (!current) {
+            return null;
+        }
+        try {
+            const next = await refreshSession(current.refresh_token);
+            update(next);
+            return next.token;
+        } catch {
+            return null;
+        }
+    }, [update]);
 
     useEffect(() => {
-        setAuthInterceptors(() => token, logout);
-    }, [token, logout]);
+        setAuthInterceptors(() => sessionRef.current?.token ?? null, refresh, logout);
+    }, [refresh, logout]);
 
     return (
         <AuthContext.Provider
             value={{
-                token,
-                isAuthenticated: !!token,
+                token: session?.token ?? null,
+                isAuthenticated: !!session,
                 login,
                 logout,
             }}
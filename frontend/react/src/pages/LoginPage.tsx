@@ -1,36 +1,59 @@
 import { useState } from "react";
 import { useNavigate } from "react-router-dom";
 import toast from "react-hot-toast";
+import { isAxiosError } from "axios";
 import { useAuth } from "../hooks/useAuth";
-import { validateApiKey } from "../api/auth";
+import { loginWithPassword, validateApiKey } from "../api/auth";
+
+type LoginMode = "password" | "api-key";
 
 export default function LoginPage() {
+    const [mode, setMode] = useState<LoginMode>("password");
+    const [username, setUsername] = useState("");
+    const [password, setPassword] = useState("");
     const [apiKey, setApiKey] = useState("");
     const [isLoading, setIsLoading] = useState(false);
     const { login } = useAuth();
     const navigate = useNavigate();
 
+    const isPassword = mode === "password";
+    const canSubmit = isPassword ? username.trim() !== "" && password !== "" : apiKey.trim() !== "";
+
     const handleSubmit = async (e: React.FormEvent) => {
         e.preventDefault();
 
-        if (!apiKey.trim()) {
-            toast.error("Please enter your API key");
+        if (!canSubmit) {
+            toast.error(isPassword ? "Please enter your username and password" : "Please enter your API key");
             return;
         }
 
         setIsLoading(true);
         try {
-            const token = await validateApiKey(apiKey.trim());
-            login(token);
+            const session = isPassword
+                ? await loginWithPassword(username.trim(), password)
+                : await validateApiKey(apiKey.trim());
+            login(session);
             toast.success("Authenticated successfully!");
             navigate("/cars");
-        } catch {
-            toast.error("Invalid API key. Please try again.");
+        } catch (error) {
+            if (isAxiosError(error) && error.response?.status === 429) {
+                toast.error("Too many failed attempts. Please try again later.");
+            } else if (isPassword) {
+                toast.error("Invalid username or password. Please try again.");
+            } else {
+                toast.error("Invalid API key. Please try again.");
+            }
         } finally {
             setIsLoading(false);
         }
     };
 
+    const switchMode = () => {
+        setMode(isPassword ? "api-key" : "password");
+        setPassword("");
+        setApiKey("");
+    };
+
     return (
         <div className="login-page">
             <div className="login-card">
@@ -38,31 +61,69 @@ export default function LoginPage() {
                     <span className="login-card__icon">🚗</span>
                     <h1 className="login-card__title">Cars CRUD</h1>
                     <p className="login-card__subtitle">
-                        Enter your API key to access the dashboard
+                        {isPassword
+                            ? "Sign in with your account to access the dashboard"
+                            : "Enter your API key to access the dashboard"}
                     </p>
                 </div>
 
                 <form onSubmit={handleSubmit} className="login-form">
-                    <div className="form-group">
-                        <label className="form-label" htmlFor="api-key">
-                            API Key
-                        </label>
-                        <input
-                            id="api-key"
-                            type="password"
-                            className="form-input"
-                            placeholder="Enter your API key..."
-                            value={apiKey}
-                            onChange={(e) => setApiKey(e.target.value)}
-                            disabled={isLoading}
-                            autoFocus
-                        />
-                    </div>
+                    {isPassword ? (
+                        <>
+                            <div className="form-group">
+                                <label className="form-label" htmlFor="username">
+                                    Username
+                                </label>
+                                <input
+                                    id="username"
+                                    type="text"
+                                    className="form-input"
+                                    placeholder="Enter your username..."
+                                    autoComplete="username"
+                                    value={username}
+                                    onChange={(e) => setUsername(e.target.value)}
+                                    disabled={isLoading}
+                                    autoFocus
+                                />
+                            </div>
+                            <div className="form-group">
+                                <label className="form-label" htmlFor="password">
+                                    Password
+                                </label>
+                                <input
+                                    id="password"
+                                    type="password"
+                                    className="form-input"
+                                    placeholder="Enter your password..."
+                                    autoComplete="current-password"
+                                    value={password}
+                                    onChange={(e) => setPassword(e.target.value)}
+                                    disabled={isLoading}
+                                />
+                            </div>
+                        </>
+                    ) : (
+                        <div className="form-group">
+                            <label className="form-label" htmlFor="api-key">
+                                API Key
+                            </label>
+                            <input
+                                id="api-key"
+                                type="password"
+                                className="form-input"
+                                placeholder="Enter your API key..."
+                                value={apiKey}
+                                onChange={(e) => setApiKey(e.target.value)}
+                                disabled={isLoading}
+                                autoFocus
+                            />
+                        </div>
+                    )}
 
                     <button
                         type="submit"
                         className="btn btn--primary btn--lg btn--full"
-                        disabled={isLoading || !apiKey.trim()}
+                        disabled={isLoading || !canSubmit}
                     >
                         {isLoading ? (
                             <span className="btn-loading">
@@ -74,8 +135,21 @@ export default function LoginPage() {
                     </button>
                 </form>
 
+                <button
+                    type="button"
+                    className="btn btn--ghost btn--full login-card__switch"
+                    onClick={switchMode}
+                    disabled={isLoading}
+                >
+                    {isPassword ? "Use an API key instead" : "Sign in with a password"}
+                </button>
+
                 <p className="login-card__hint">
-                    The API key is configured in the backend <code>.env</code> file
+                    {isPassword ? (
+                        <>Accounts are created by an admin under <code>/admin/users</code></>
+                    ) : (
+                        <>The API key is configured in the backend <code>.env</code> file</>
+                    )}
                 </p>
             </div>
         </div>
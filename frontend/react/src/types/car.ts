@@ -46,4 +46,7 @@ export interface ValidateRequest {
 
 export interface ValidateResponse {
     token: string;
+    refresh_token: string;
+    token_type: string;
+    expires_in: number;
 }